@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
+	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// captureTraffic records everything the port sends, without writing anything to it
+func captureTraffic(args []string) {
+	flags := flag.NewFlagSet("capture", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	out := flags.String("out", "capture"+capture.FileExtension, "Capture file to write")
+	maxSize := flags.Int64("max-size", 0, "Start a new capture file after this many bytes (0 for no limit)")
+	maxDuration := flags.Duration("max-duration", 0, "Start a new capture file after this long (0 for no limit)")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		fmt.Println("Error: Serial port is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	recorder := capture.NewRecorder(*out, *maxSize, *maxDuration)
+	defer recorder.Close()
+
+	port := capture.NewRecordingTransport(sc.NewTransport(*portName, int32(*baudRate)), recorder)
+	if err := port.SetReadTimeout(100 * time.Millisecond); err != nil {
+		fmt.Printf("Failed to set read timeout: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if err := port.Open(); err != nil {
+		fmt.Printf("Failed to open port %s: %s\n", *portName, err.Error())
+		os.Exit(1)
+	}
+	defer port.Close()
+
+	// Handle Ctrl-C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	fmt.Printf("Capturing %s at %d baud to %s...\n", *portName, *baudRate, recorder.FileName(0))
+
+	buf := make([]byte, 1024)
+	total := 0
+	lastReport := time.Now()
+
+Loop:
+	for {
+		select {
+		case <-sigChan:
+			break Loop
+		default:
+		}
+
+		count, err := port.Read(buf)
+		if err != nil {
+			fmt.Printf("Error reading port %s: %s\n", *portName, err.Error())
+			break Loop
+		}
+
+		total += count
+		if time.Since(lastReport) >= 5*time.Second {
+			lastReport = time.Now()
+			fmt.Printf("Captured %d bytes\n", total)
+		}
+	}
+
+	fmt.Printf("\nCaptured %d bytes\n", total)
+}
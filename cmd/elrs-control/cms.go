@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	lc "github.com/kaack/elrs-joystick-control/pkg/link"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"os"
+	"strings"
+	"time"
+)
+
+// cmsKeys maps the letters typed in the cms subcommand to stick commands
+var cmsKeys = map[rune]lc.CMSKey{
+	'w': lc.CMSKeyUp,
+	's': lc.CMSKeyDown,
+	'a': lc.CMSKeyLeft,
+	'd': lc.CMSKeyRight,
+	'q': lc.CMSKeyEsc,
+	'e': lc.CMSKeySaveMenu,
+	'm': lc.CMSKeyMenu,
+}
+
+const cmsHelp = "w/s up/down, a/d left/right, q esc, e save, m menu, p redraw, x exit (several keys per line, then Enter)"
+
+// openCMS shows the CMS menu of the flight controller, and navigates it with stick commands typed as letters
+func openCMS(args []string) {
+	flags := flag.NewFlagSet("cms", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	rows := flags.Int("rows", 16, "Rows of the screen the menu is drawn on")
+	cols := flags.Int("cols", 32, "Columns of the screen the menu is drawn on")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	//sticks centered, throttle low, disarmed
+	var channels [16]util.CRSFValue
+	for i := range channels {
+		channels[i] = lc.StickMid
+	}
+	channels[lc.ThrottleChannel] = lc.StickLow
+	channels[lc.ArmChannel] = lc.DisarmedValue
+	linkCtl.UpdateChannels(channels)
+
+	go func() {
+		for screen := range linkCtl.DisplayPortChan {
+			_ = screen.Render(os.Stdout)
+			fmt.Println(cmsHelp)
+		}
+	}()
+
+	if err := linkCtl.OpenCMS(*rows, *cols); err != nil {
+		fmt.Printf("Error opening cms menu: %s\n", err.Error())
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		for _, c := range strings.ToLower(scanner.Text()) {
+			switch c {
+			case 'x':
+				_ = linkCtl.CloseCMS()
+				//let the close go out with the next channels
+				time.Sleep(100 * time.Millisecond)
+				return
+			case 'p':
+				_ = linkCtl.PollCMS()
+			default:
+				key, ok := cmsKeys[c]
+				if !ok {
+					continue
+				}
+				if err := linkCtl.PressCMSKey(key); err != nil {
+					fmt.Printf("Error pressing %s: %s\n", key, err.Error())
+				}
+				//hold the key, then center the sticks before the next one
+				time.Sleep(2 * lc.CMSKeyHold)
+			}
+		}
+	}
+	_ = linkCtl.CloseCMS()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	lc "github.com/kaack/elrs-joystick-control/pkg/link"
+	"os"
+	"os/signal"
+)
+
+// listDevices prints the devices that answer a ping, and with -watch keeps printing their events
+func listDevices(args []string) {
+	flags := flag.NewFlagSet("devices", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	watch := flags.Bool("watch", false, "Keep running, and print devices as they appear, change or go silent")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		fmt.Println("Error: Serial port is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	if !*watch {
+		for _, device := range linkCtl.Devices() {
+			printDevice(device)
+		}
+		return
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	for {
+		select {
+		case event := <-linkCtl.DeviceEventChan:
+			fmt.Printf("%s: ", event.Type)
+			printDevice(event.Device)
+		case <-sigChan:
+			return
+		}
+	}
+}
+
+func printDevice(device lc.DeviceInfo) {
+	fmt.Printf("%x %s (serial %d, hardware %s, firmware %s, %d fields, parameter version %d)\n",
+		uint8(device.Id), device.Name, device.SerialNumber, device.HardwareVersion, device.SoftwareVersion,
+		device.FieldCount, device.ParameterVersion)
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
 	lc "github.com/kaack/elrs-joystick-control/pkg/link"
 	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
 	"github.com/kaack/elrs-joystick-control/pkg/util"
@@ -12,9 +15,64 @@ import (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve-port":
+			servePort(os.Args[2:])
+			return
+		case "capture":
+			captureTraffic(os.Args[2:])
+			return
+		case "sniff":
+			sniffLine(os.Args[2:])
+			return
+		case "relay":
+			relayHandset(os.Args[2:])
+			return
+		case "devices":
+			listDevices(os.Args[2:])
+			return
+		case "params":
+			listParameters(os.Args[2:])
+			return
+		case "set":
+			setParameter(os.Args[2:])
+			return
+		case "run":
+			runCommand(os.Args[2:])
+			return
+		case "msp":
+			sendMSP(os.Args[2:])
+			return
+		case "cms":
+			openCMS(os.Args[2:])
+			return
+		case "pcap":
+			exportPcap(os.Args[2:])
+			return
+		case "simulate":
+			simulate(os.Args[2:])
+			return
+		}
+	}
+
 	// Command line flags
-	txPortName := flag.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3)")
+	txPortName := flag.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760, replay://capture.crsfcap, or auto)")
 	txBaudRate := flag.Int("baud", 921600, "Serial port baud rate")
+	txSpeed := flag.Int("speed", 0, "Baud rate to negotiate with the TX module once the link is up (0 keeps -baud)")
+	replaySpeed := flag.Float64("replay-speed", 1, "Replay speed for replay:// ports (1 is the original pace, 0 as fast as possible)")
+	replayStep := flag.Bool("replay-step", false, "Replay one chunk each time Enter is pressed")
+	capturePath := flag.String("capture", "", "Record all port traffic to this capture file")
+	captureMaxSize := flag.Int64("capture-max-size", 0, "Start a new capture file after this many bytes (0 for no limit)")
+	captureMaxDuration := flag.Duration("capture-max-duration", 0, "Start a new capture file after this long (0 for no limit)")
+	channelResolution := flag.Int("channel-resolution", 0, "Send the subset channels frame at this resolution (10 to 13 bits, 0 sends the classic 16 channels, 11-bit frame)")
+	channelStart := flag.Int("channel-start", 0, "First channel sent in the subset channels frame")
+	channelCount := flag.Int("channel-count", 16, "Number of channels sent in the subset channels frame")
+	modelId := flag.Uint("model-id", 0, fmt.Sprintf("Model id sent to the TX module for ELRS model match (0 to %d)", lc.MaxModelId))
+	mavlinkUDP := flag.String("mavlink-udp", "", "Bridge the MAVLink stream (ELRS MAVLink mode) to a ground control station, listening on this UDP address (e.g. :14555)")
+	mavlinkGCS := flag.String("mavlink-gcs", lc.DefaultGCSAddress, "UDP address of the ground control station, empty replies to whoever sent the last datagram")
+	refuseArm := flag.Bool("refuse-arm-on-mismatch", false, "Hold the arm channel (AUX1) low while the receiver reports a model mismatch")
 	flag.Parse()
 
 	if *txPortName == "" {
@@ -30,9 +88,84 @@ func main() {
 	linkCtl := lc.NewCtl(serialCtl)
 	defer linkCtl.Quit()
 
+	// Find the TX module
+	if *txPortName == "auto" {
+		fmt.Println("Discovering TX modules...")
+		modules, err := linkCtl.DiscoverModules(lc.DiscoveryProbeTimeout)
+		if err != nil {
+			fmt.Printf("Failed to discover TX modules: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		if len(modules) != 1 {
+			fmt.Printf("Error: expected exactly one TX module, but found %d\n", len(modules))
+			for _, module := range modules {
+				fmt.Printf("  %s at %d baud: %s (serial %d, firmware %s)\n",
+					module.Port, module.BaudRate, module.Name, module.SerialNumber, module.SoftwareVersion)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Found %s (serial %d, firmware %s)\n", modules[0].Name, modules[0].SerialNumber, modules[0].SoftwareVersion)
+		*txPortName = modules[0].Port
+		*txBaudRate = int(modules[0].BaudRate)
+	}
+
+	// Choose the channels frame
+	if *channelResolution != 0 {
+		if *channelStart < 0 || *channelStart >= crsf.MaxChannels || *channelCount < 1 || *channelCount > crsf.MaxChannels {
+			fmt.Printf("Error: channels %d to %d are out of range, there are %d channels\n", *channelStart, *channelStart+*channelCount-1, crsf.MaxChannels)
+			os.Exit(1)
+		}
+
+		encoding := lc.ChannelEncoding{
+			Subset:       true,
+			Resolution:   crsf.ChannelResolution(*channelResolution),
+			StartChannel: uint8(*channelStart),
+			Count:        uint8(*channelCount),
+		}
+		if err := linkCtl.SetChannelEncoding(encoding); err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// Select the model
+	if *modelId > lc.MaxModelId {
+		fmt.Printf("Error: model id %d is out of range, at most %d\n", *modelId, lc.MaxModelId)
+		os.Exit(1)
+	}
+	if err := linkCtl.SetModelId(uint8(*modelId)); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+	linkCtl.SetRefuseArmOnModelMismatch(*refuseArm)
+
+	// Record the session
+	if *capturePath != "" {
+		recorder := capture.NewRecorder(*capturePath, *captureMaxSize, *captureMaxDuration)
+		defer recorder.Close()
+		linkCtl.SetRecorder(recorder)
+	}
+
 	// Start the RF link
 	fmt.Printf("Starting RF link on %s at %d baud...\n", *txPortName, *txBaudRate)
-	if err := linkCtl.StartSupervisor(*txPortName, int32(*txBaudRate)); err != nil {
+	var txPort sc.Transport
+	var replayDone <-chan any
+	if capture.IsReplayName(*txPortName) {
+		replay := capture.NewReplayFromName(*txPortName, int32(*txBaudRate))
+		replay.Speed = *replaySpeed
+		replay.Stepwise = *replayStep
+		if replay.Stepwise {
+			go stepReplay(replay)
+		}
+		replayDone = replay.Done()
+		txPort = replay
+	} else {
+		txPort = sc.NewTransport(*txPortName, int32(*txBaudRate))
+	}
+
+	if err := linkCtl.StartSupervisor(txPort); err != nil {
 		fmt.Printf("Failed to start link: %s\n", err.Error())
 		os.Exit(1)
 	}
@@ -54,6 +187,28 @@ func main() {
 	// Give the handshake a moment to complete
 	time.Sleep(500 * time.Millisecond)
 
+	// Move to a faster baud rate
+	if *txSpeed != 0 && *txSpeed != *txBaudRate {
+		fmt.Printf("Negotiating %d baud...\n", *txSpeed)
+		if err := linkCtl.NegotiateBaudRate(int32(*txSpeed)); err != nil {
+			fmt.Printf("Baud rate negotiation failed, staying at %d baud: %s\n", *txBaudRate, err.Error())
+		} else {
+			fmt.Printf("Link running at %d baud\n", *txSpeed)
+		}
+	}
+
+	// Bridge MAVLink to the ground control station
+	if *mavlinkUDP != "" {
+		bridge := &lc.MAVLinkUDPBridge{Stream: linkCtl.MAVLink(), Address: *mavlinkUDP, Remote: *mavlinkGCS}
+		if err := bridge.Start(); err != nil {
+			fmt.Printf("Failed to start MAVLink bridge: %s\n", err.Error())
+			os.Exit(1)
+		}
+		defer func() {
+			_ = bridge.Stop()
+		}()
+	}
+
 	// Set up telemetry monitoring (optional)
 	go monitorTelemetry(linkCtl)
 
@@ -64,8 +219,11 @@ func main() {
 	// Example control loop
 	go controlLoop(linkCtl)
 
-	// Wait for interrupt
-	<-sigChan
+	// Wait for interrupt, or for the replay to finish
+	select {
+	case <-sigChan:
+	case <-replayDone:
+	}
 	fmt.Println("\nShutting down...")
 
 	// Safe shutdown - disarm and zero throttle
@@ -82,6 +240,14 @@ func main() {
 	}
 }
 
+func stepReplay(replay *capture.ReplayTransport) {
+	fmt.Println("Press Enter to replay the next chunk")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		replay.Step()
+	}
+}
+
 func monitorTelemetry(linkCtl *lc.Controller) {
 	for {
 		select {
@@ -102,6 +268,45 @@ func monitorTelemetry(linkCtl *lc.Controller) {
 		case attitude := <-linkCtl.AttitudeChan:
 			fmt.Printf("Attitude: Pitch=%.1f° Roll=%.1f° Yaw=%.1f°\n",
 				attitude.Pitch, attitude.Roll, attitude.Yaw)
+
+		case rpm := <-linkCtl.RPMChan:
+			fmt.Printf("RPM[%d]: %v\n", rpm.SourceId, rpm.RPM)
+
+		case temperature := <-linkCtl.TemperatureChan:
+			fmt.Printf("Temperature[%d]: %v°C\n", temperature.SourceId, temperature.Temperatures)
+
+		case cells := <-linkCtl.CellsChan:
+			fmt.Printf("Cells[%d]: %vV\n", cells.SourceId, cells.Voltages)
+
+		case airspeed := <-linkCtl.AirspeedChan:
+			fmt.Printf("Airspeed: %.1fkm/h\n", airspeed.Speed)
+
+		case gpsTime := <-linkCtl.GPSTimeChan:
+			fmt.Printf("GPS time: %s\n", gpsTime.Time.Format(time.RFC3339))
+
+		case gpsExt := <-linkCtl.GPSExtendedChan:
+			fmt.Printf("GPS extended: Fix=%d Vel(N/E/U)=%.2f/%.2f/%.2fm/s HAcc=%.2fm VAcc=%.2fm HDOP=%.1f VDOP=%.1f\n",
+				gpsExt.FixType, gpsExt.NorthVelocity, gpsExt.EastVelocity, gpsExt.UpVelocity,
+				gpsExt.HorizontalAccuracy, gpsExt.VerticalAccuracy, gpsExt.HDOP, gpsExt.VDOP)
+
+		case value := <-linkCtl.ArduPilotChan:
+			switch v := value.(type) {
+			case crsf.APStatusText:
+				// already logged by the link
+			case crsf.APStatus:
+				fmt.Printf("ArduPilot: Mode=%d Armed=%v Flying=%v Throttle=%.0f%% Failsafe=%v\n",
+					v.FlightMode, v.Armed, v.Flying, v.Throttle, v.Failsafe)
+			default:
+				fmt.Printf("ArduPilot: %T %+v\n", v, v)
+			}
+
+		case <-linkCtl.HeartbeatChan:
+			// devices are tracked by the link, nothing to show
+
+		case status := <-linkCtl.StatusChan:
+			if status.ModelMismatch {
+				fmt.Printf("Status: receiver model does not match model id %d\n", linkCtl.GetModelId())
+			}
 		}
 	}
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sendMSP sends a single MSP request to the flight controller, and prints its answer
+func sendMSP(args []string) {
+	flags := flag.NewFlagSet("msp", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	timeout := flags.Duration("timeout", 2*time.Second, "How long to wait for the answer")
+	flags.Usage = func() {
+		fmt.Println("Usage: elrs-control msp -port <port> [flags] <command, e.g. 2 for MSP_FC_VARIANT> [payload as hex]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *portName == "" || flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	command, err := strconv.ParseUint(flags.Arg(0), 0, 16)
+	if err != nil {
+		fmt.Printf("Error: invalid command %q\n", flags.Arg(0))
+		os.Exit(1)
+	}
+
+	var payload []byte
+	if flags.NArg() == 2 {
+		if payload, err = hex.DecodeString(flags.Arg(1)); err != nil {
+			fmt.Printf("Error: invalid payload %q. %s\n", flags.Arg(1), err.Error())
+			os.Exit(1)
+		}
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	response, err := linkCtl.MSP(ctx, uint16(command), payload)
+	if err != nil {
+		fmt.Printf("MSP command %d failed: %s\n", command, err.Error())
+		return
+	}
+
+	fmt.Printf("%d bytes: %x\n%q\n", len(response), response, response)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+	lc "github.com/kaack/elrs-joystick-control/pkg/link"
+	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listParameters loads and prints the parameter tree of a device
+func listParameters(args []string) {
+	flags := flag.NewFlagSet("params", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	deviceId := flags.Uint("device", uint(crossfire.ModuleEndpoint), "Device address (0xEE is the TX module, 0xEC the receiver)")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		fmt.Println("Error: Serial port is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	tree, err := linkCtl.LoadParameters(crossfire.Endpoint(*deviceId))
+	if err != nil {
+		fmt.Printf("Failed to load parameters: %s\n", err.Error())
+		return
+	}
+
+	fmt.Print(tree)
+}
+
+// setParameter writes a single parameter of a device, the field is given by its id or its folder path
+func setParameter(args []string) {
+	flags := flag.NewFlagSet("set", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	deviceId := flags.Uint("device", uint(crossfire.ModuleEndpoint), "Device address (0xEE is the TX module, 0xEC the receiver)")
+	flags.Usage = func() {
+		fmt.Println("Usage: elrs-control set -port <port> [flags] <field id or path, e.g. \"TX Power/Max Power\"> <value>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *portName == "" || flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	tree, err := linkCtl.LoadParameters(crossfire.Endpoint(*deviceId))
+	if err != nil {
+		fmt.Printf("Failed to load parameters: %s\n", err.Error())
+		return
+	}
+
+	field := findField(tree, flags.Arg(0))
+	if field == nil {
+		fmt.Printf("Error: no field %q\n", flags.Arg(0))
+		return
+	}
+
+	value, err := settings.ParseValue(field, flags.Arg(1))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return
+	}
+
+	updated, err := linkCtl.WriteParameter(crossfire.Endpoint(*deviceId), field, value)
+	if err != nil {
+		fmt.Printf("Failed to write %s: %s\n", field.Name(), err.Error())
+		return
+	}
+
+	fmt.Println(updated)
+}
+
+// runCommand clicks a command parameter of a device, and follows it until it is done
+func runCommand(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	deviceId := flags.Uint("device", uint(crossfire.ModuleEndpoint), "Device address (0xEE is the TX module, 0xEC the receiver)")
+	yes := flags.Bool("yes", false, "Confirm without asking")
+	timeout := flags.Duration("timeout", 30*time.Second, "Give up after this long")
+	flags.Usage = func() {
+		fmt.Println("Usage: elrs-control run -port <port> [flags] <command id or path, e.g. \"Bind\">")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *portName == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := startLink(*portName, int32(*baudRate))
+	defer stopLink(linkCtl)
+
+	tree, err := linkCtl.LoadParameters(crossfire.Endpoint(*deviceId))
+	if err != nil {
+		fmt.Printf("Failed to load parameters: %s\n", err.Error())
+		return
+	}
+
+	field := findField(tree, flags.Arg(0))
+	if field == nil {
+		fmt.Printf("Error: no field %q\n", flags.Arg(0))
+		return
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	confirm := func(command settings.CommandFieldType) bool {
+		if *yes {
+			return true
+		}
+		fmt.Printf("%s [y/N] ", command.Message())
+		answer, _ := stdin.ReadString('\n')
+		return strings.EqualFold(strings.TrimSpace(answer), "y")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	message, err := linkCtl.RunCommand(ctx, crossfire.Endpoint(*deviceId), field, confirm)
+	if err != nil {
+		fmt.Printf("Failed to run %s: %s\n", field.Name(), err.Error())
+		return
+	}
+
+	fmt.Printf("%s: %s\n", field.Name(), message)
+}
+
+// findField looks a field up by its id, or by its folder path (e.g. "TX Power/Max Power")
+func findField(tree *settings.Tree, name string) settings.FieldType {
+	if id, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return tree.Field(uint32(id))
+	}
+	if node := tree.Find(strings.Split(name, "/")...); node != nil {
+		return node.Field
+	}
+	return nil
+}
+
+// startLink starts the RF link, and waits for it to become active
+func startLink(portName string, baudRate int32) *lc.Controller {
+	linkCtl := lc.NewCtl(sc.NewCtl())
+
+	fmt.Printf("Starting RF link on %s at %d baud...\n", portName, baudRate)
+	if err := linkCtl.StartSupervisor(sc.NewTransport(portName, baudRate)); err != nil {
+		fmt.Printf("Failed to start link: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for !linkCtl.IsActive() {
+		select {
+		case <-timeout:
+			fmt.Println("Timeout waiting for link to become active")
+			os.Exit(1)
+		default:
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	// Give the handshake a moment to complete
+	time.Sleep(500 * time.Millisecond)
+	return linkCtl
+}
+
+func stopLink(linkCtl *lc.Controller) {
+	if err := linkCtl.StopSupervisor(); err != nil {
+		fmt.Printf("Error stopping link: %s\n", err.Error())
+	}
+	linkCtl.Quit()
+}
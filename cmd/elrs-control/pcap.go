@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportPcap converts raw captures into a pcapng file for Wireshark, rotated captures can be passed in order
+func exportPcap(args []string) {
+	flags := flag.NewFlagSet("pcap", flag.ExitOnError)
+	out := flags.String("out", "", "pcapng file to write (defaults to the first capture's name with a .pcapng extension)")
+	flags.Usage = func() {
+		fmt.Println("Usage: elrs-control pcap [-out file.pcapng] capture.crsfcap...")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Error: at least one capture file is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		first := flags.Arg(0)
+		*out = strings.TrimSuffix(first, filepath.Ext(first)) + capture.PcapngFileExtension
+	}
+
+	outFile, err := os.Create(*out)
+	if err != nil {
+		fmt.Printf("Failed to create %s: %s\n", *out, err.Error())
+		os.Exit(1)
+	}
+	defer outFile.Close()
+
+	writer, err := capture.NewPcapngWriter(outFile)
+	if err != nil {
+		fmt.Printf("Failed to write %s: %s\n", *out, err.Error())
+		os.Exit(1)
+	}
+
+	exporter := capture.NewPcapngExporter(writer)
+	for _, name := range flags.Args() {
+		if err = exportCapture(exporter, name); err != nil {
+			fmt.Printf("Failed to convert %s: %s\n", name, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Wrote %s\n", *out)
+}
+
+func exportCapture(exporter *capture.PcapngExporter, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := capture.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	return exporter.Export(reader)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	lc "github.com/kaack/elrs-joystick-control/pkg/link"
+	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+const relayHelp = "mix channels as <channel>=<value> (replace), <channel>=+<value> or =-<value> (offset), <channel>= (back to the handset), 11-bit values, channels from 1"
+
+// relayHandset puts this program between a handset and its TX module, mixing channels into the handset's
+func relayHandset(args []string) {
+	flags := flag.NewFlagSet("relay", flag.ExitOnError)
+	handsetPortName := flags.String("handset-port", "", "Serial port name of the handset's CRSF line (e.g., /dev/ttyUSB1)")
+	handsetBaudRate := flags.Int("handset-baud", 400000, "Baud rate of the handset's CRSF line, as set on the handset")
+	portName := flags.String("port", "", "Serial port name of the TX module (e.g., /dev/ttyUSB0, COM3, tcp://host:5760, udp://host:5760)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate of the TX module")
+	mixes := flags.String("mix", "", "Channels mixed from the start, comma separated (e.g. 5=172,1=+100)")
+	_ = flags.Parse(args)
+
+	if *handsetPortName == "" || *portName == "" {
+		fmt.Println("Error: both serial ports are required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	linkCtl := lc.NewCtl(sc.NewCtl())
+	relay := lc.NewRelay(sc.NewTransport(*handsetPortName, int32(*handsetBaudRate)), linkCtl)
+
+	if *mixes != "" {
+		for _, mix := range strings.Split(*mixes, ",") {
+			if err := setRelayMix(relay, mix); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+	}
+
+	if err := relay.Start(); err != nil {
+		fmt.Printf("Failed to open handset port %s: %s\n", *handsetPortName, err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("Relaying %s at %d baud to %s at %d baud...\n", *handsetPortName, *handsetBaudRate, *portName, *baudRate)
+	if err := linkCtl.StartSupervisor(relay.ModuleTransport(sc.NewTransport(*portName, int32(*baudRate)))); err != nil {
+		fmt.Printf("Failed to start link: %s\n", err.Error())
+		_ = relay.Stop()
+		os.Exit(1)
+	}
+
+	// Handle Ctrl-C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	go func() {
+		fmt.Println(relayHelp)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				if err := setRelayMix(relay, line); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+				}
+			}
+		}
+	}()
+
+	<-sigChan
+
+	if err := relay.Stop(); err != nil {
+		fmt.Printf("Error stopping relay: %s\n", err.Error())
+	}
+	stopLink(linkCtl)
+	fmt.Printf("\nRelayed %d frames of the handset, %d errors\n", relay.FramesCount, relay.ErrorsCount)
+}
+
+// setRelayMix parses <channel>=<value>, <channel>=+<value>, <channel>=-<value> or <channel>=
+func setRelayMix(relay *lc.Relay, mix string) error {
+	channelText, valueText, found := strings.Cut(strings.TrimSpace(mix), "=")
+	if !found {
+		return errors.New(fmt.Sprintf("cannot parse mix %q, expected <channel>=<value>", mix))
+	}
+
+	channel, err := strconv.Atoi(channelText)
+	if err != nil {
+		return errors.New(fmt.Sprintf("cannot parse channel %q", channelText))
+	}
+
+	if valueText == "" {
+		fmt.Printf("channel %d: handset\n", channel)
+		return relay.SetMix(channel-1, lc.ChannelMix{Mode: lc.MixHandset})
+	}
+
+	value, err := strconv.Atoi(valueText)
+	if err != nil {
+		return errors.New(fmt.Sprintf("cannot parse value %q", valueText))
+	}
+
+	mode := lc.MixReplace
+	if valueText[0] == '+' || valueText[0] == '-' {
+		//an offset is a value around the center
+		mode = lc.MixOffset
+		value += int(crsf.Resolution11Bit.MaxValue()+1) / 2
+	}
+	if value < 0 || value > int(crsf.Resolution11Bit.MaxValue()) {
+		return errors.New(fmt.Sprintf("value %s is out of the 11-bit range", valueText))
+	}
+
+	fmt.Printf("channel %d: %v %s\n", channel, mode, valueText)
+	return relay.SetMix(channel-1, lc.ChannelMix{
+		Mode:  mode,
+		Value: crsf.ScaleChannel(util.CRSFValue(value), crsf.Resolution11Bit, lc.ChannelModelResolution),
+	})
+}
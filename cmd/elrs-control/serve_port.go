@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
+	"os"
+	"os/signal"
+)
+
+// servePort exposes a local serial port on the network, so elrs-control can run on another machine
+// with -port tcp://host:port or -port udp://host:port
+func servePort(args []string) {
+	flags := flag.NewFlagSet("serve-port", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name (e.g., /dev/ttyUSB0, COM3)")
+	baudRate := flags.Int("baud", 921600, "Serial port baud rate")
+	network := flags.String("network", "tcp", "Network to listen on (tcp or udp)")
+	listen := flags.String("listen", ":5760", "Address to listen on")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		fmt.Println("Error: Serial port is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	bridge := &sc.NetBridge{
+		Port:    &sc.Port{Name: *portName, BaudRate: int32(*baudRate)},
+		Network: *network,
+		Address: *listen,
+	}
+
+	fmt.Printf("Serving %s at %d baud on %s://%s...\n", *portName, *baudRate, *network, *listen)
+	if err := bridge.Start(); err != nil {
+		fmt.Printf("Failed to start bridge: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// Handle Ctrl-C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	<-sigChan
+	fmt.Println("\nShutting down...")
+
+	if err := bridge.Stop(); err != nil {
+		fmt.Printf("Error stopping bridge: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/simulator"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// simulate runs a software ELRS TX module behind a pseudo-terminal, point elrs-control's -port at the printed slave name
+func simulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	name := flags.String("name", "ELRS Simulator", "Device name reported to ping requests")
+	packetRate := flags.Duration("rate", 4*time.Millisecond, "Air packet period reported in the sync frames")
+	syncInterval := flags.Duration("sync-interval", 200*time.Millisecond, "How often sync frames are sent (0 sends none)")
+	mavlinkEcho := flags.Bool("mavlink-echo", false, "Send the MAVLink bytes received in envelope frames back")
+	sensors := flags.Bool("sensors", false, "Send rpm, temperature, cells, airspeed, heartbeat and extended gps telemetry every second")
+	ardupilot := flags.Bool("ardupilot", false, "Send ArduPilot passthrough telemetry, including status texts")
+	rxModelId := flags.Int("rx-model-id", -1, "Report a model mismatch unless this model id is selected (-1 accepts every model)")
+	_ = flags.Parse(args)
+
+	master, slaveName, err := simulator.OpenPty()
+	if err != nil {
+		fmt.Printf("Failed to open pseudo-terminal: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	config := simulator.DefaultConfig()
+	config.Device.Name = *name
+	config.PacketRate = *packetRate
+	config.SyncInterval = *syncInterval
+	config.ReceiverModelId = *rxModelId
+	config.MavlinkEcho = *mavlinkEcho
+	if *sensors {
+		config.SensorInterval = 1 * time.Second
+	}
+	if *ardupilot {
+		config.PassthroughInterval = 100 * time.Millisecond
+	}
+
+	module := simulator.NewModule(master, config)
+	if err = module.Start(); err != nil {
+		fmt.Printf("Failed to start simulator: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("Simulated TX module listening on %s\n", slaveName)
+	fmt.Printf("Run: elrs-control -port %s\n", slaveName)
+
+	// Handle Ctrl-C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+Loop:
+	for {
+		select {
+		case <-sigChan:
+			break Loop
+		case <-ticker.C:
+			good, bad := module.ChannelFrameCounts()
+			fmt.Printf("Channels: good=%d bad=%d model=%d values=%v\n", good, bad, module.ModelId(), module.Channels())
+		}
+	}
+
+	fmt.Println("\nShutting down...")
+	if err = module.Stop(); err != nil {
+		fmt.Printf("Error stopping simulator: %s\n", err.Error())
+	}
+}
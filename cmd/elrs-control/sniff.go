@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	lc "github.com/kaack/elrs-joystick-control/pkg/link"
+	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// sniffLine prints the frames going both ways on the line between a handset and its module, without writing anything to it
+func sniffLine(args []string) {
+	flags := flag.NewFlagSet("sniff", flag.ExitOnError)
+	portName := flags.String("port", "", "Serial port name of the UART tapped into the line (e.g., /dev/ttyUSB0, COM3)")
+	baudRate := flags.Int("baud", 400000, "Baud rate of the line, as set on the handset")
+	channelInterval := flags.Duration("channel-interval", time.Second, "Print the channels of the handset at most this often (0 prints every channels frame)")
+	_ = flags.Parse(args)
+
+	if *portName == "" {
+		fmt.Println("Error: Serial port is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	sniffer := lc.NewSniffer(sc.NewTransport(*portName, int32(*baudRate)))
+	if err := sniffer.Start(); err != nil {
+		fmt.Printf("Failed to open port %s: %s\n", *portName, err.Error())
+		os.Exit(1)
+	}
+
+	// Handle Ctrl-C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	fmt.Printf("Sniffing %s at %d baud...\n", *portName, *baudRate)
+
+	var lastChannels time.Time
+	skippedChannels := 0
+
+Loop:
+	for {
+		select {
+		case <-sigChan:
+			break Loop
+		case frame, ok := <-sniffer.FrameChan:
+			if !ok {
+				break Loop
+			}
+
+			timestamp := frame.Time.Format("15:04:05.000")
+			if frame.Channels != nil {
+				if frame.Time.Sub(lastChannels) < *channelInterval {
+					skippedChannels += 1
+					continue
+				}
+				lastChannels = frame.Time
+				fmt.Printf("%s %-15s channels %v (%d frames skipped)\n", timestamp, frame.Direction, *frame.Channels, skippedChannels)
+				skippedChannels = 0
+				continue
+			}
+
+			fmt.Printf("%s %-15s %v\n", timestamp, frame.Direction, frame.Frame)
+		}
+	}
+
+	_ = sniffer.Stop()
+	fmt.Printf("\nSniffed %d frames, %d errors\n", sniffer.FramesCount, sniffer.ErrorsCount)
+}
@@ -48,7 +48,7 @@ func elrs_init(port *C.char, baudRate C.int) C.int {
 	controller = lc.NewCtl(serialCtl)
 
 	// Start the RF link
-	if err := controller.StartSupervisor(portStr, int32(baudRate)); err != nil {
+	if err := controller.StartSupervisor(sc.NewTransport(portStr, int32(baudRate))); err != nil {
 		fmt.Printf("Failed to start link: %s\n", err.Error())
 		return -2
 	}
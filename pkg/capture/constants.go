@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"fmt"
+	"time"
+)
+
+// Capture file layout:
+//
+//	header: magic(8 bytes), start time(int64, unix nanoseconds, big endian)
+//	record: direction(uint8), offset from start(uvarint, nanoseconds), length(uvarint), data(length bytes)
+//
+// Offsets come from the monotonic clock, so they are not affected by wall clock changes.
+
+var Magic = [8]byte{'C', 'R', 'S', 'F', 'C', 'A', 'P', 1}
+
+const HeaderSize = 16
+
+const FileExtension = ".crsfcap"
+
+// MaxRecordSize is the largest record data accepted when reading, records are single port reads of a few KiB
+const MaxRecordSize = 64 * 1024
+
+const flushInterval = 1 * time.Second
+
+type Direction uint8
+
+const (
+	DirectionTx Direction = iota // written to the port (handset -> module)
+	DirectionRx Direction = iota // read from the port (module -> handset)
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirectionTx:
+		return "tx"
+	case DirectionRx:
+		return "rx"
+	default:
+		return fmt.Sprintf("%d", int(d))
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"errors"
+	"fmt"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"io"
+	"time"
+)
+
+// PcapngExporter splits raw chunks into CRSF frames and writes one packet per frame.
+// Bytes that do not form a valid frame are kept as a single annotated packet per malformed span.
+type PcapngExporter struct {
+	Writer *PcapngWriter
+
+	streams [2]frameStream
+}
+
+type frameStream struct {
+	buffer    []byte
+	malformed []byte
+	reason    string
+	timestamp time.Time
+}
+
+func NewPcapngExporter(writer *PcapngWriter) *PcapngExporter {
+	return &PcapngExporter{Writer: writer}
+}
+
+// Export converts a whole capture
+func (e *PcapngExporter) Export(reader *Reader) error {
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return e.Flush()
+			}
+			return err
+		}
+
+		if err = e.WriteChunk(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *PcapngExporter) WriteChunk(chunk *Chunk) error {
+	if int(chunk.Direction) >= len(e.streams) {
+		return errors.New(fmt.Sprintf("unknown direction %d", chunk.Direction))
+	}
+
+	stream := &e.streams[chunk.Direction]
+	stream.buffer = append(stream.buffer, chunk.Data...)
+	stream.timestamp = chunk.Time
+
+	return e.split(chunk.Direction, false)
+}
+
+// Flush writes out whatever is left in the buffers as malformed packets
+func (e *PcapngExporter) Flush() error {
+	for direction := range e.streams {
+		if err := e.split(Direction(direction), true); err != nil {
+			return err
+		}
+
+		stream := &e.streams[direction]
+		if len(stream.buffer) > 0 {
+			e.addMalformed(stream, stream.buffer, "incomplete frame")
+			stream.buffer = nil
+		}
+
+		if err := e.writeMalformed(Direction(direction)); err != nil {
+			return err
+		}
+	}
+
+	return e.Writer.Flush()
+}
+
+func (e *PcapngExporter) split(direction Direction, atEOF bool) error {
+	stream := &e.streams[direction]
+
+	for len(stream.buffer) > 0 {
+		skip, frame, err := telem.SplitAt(stream.buffer, atEOF, telem.IsSyncAddress)
+		if frame == nil {
+			if skip == 0 {
+				//need more data, or the rest is an incomplete frame
+				break
+			}
+
+			reason := "no frame start"
+			if err != nil {
+				reason = err.Error()
+			}
+			e.addMalformed(stream, stream.buffer[:skip], reason)
+			stream.buffer = stream.buffer[skip:]
+			continue
+		}
+
+		frameStart := skip - len(*frame)
+		if frameStart > 0 {
+			e.addMalformed(stream, stream.buffer[:frameStart], "no frame start")
+		}
+
+		if err = e.writeMalformed(direction); err != nil {
+			return err
+		}
+
+		if err = e.Writer.WritePacket(stream.timestamp, direction, *frame, ""); err != nil {
+			return err
+		}
+
+		stream.buffer = stream.buffer[skip:]
+	}
+
+	//keep the buffer from growing, the unconsumed tail is short
+	stream.buffer = append([]byte(nil), stream.buffer...)
+	return nil
+}
+
+func (e *PcapngExporter) addMalformed(stream *frameStream, data []byte, reason string) {
+	//the first reason explains the span, the bytes after it are skipped as a consequence
+	if len(stream.malformed) == 0 {
+		stream.reason = reason
+	}
+	stream.malformed = append(stream.malformed, data...)
+}
+
+func (e *PcapngExporter) writeMalformed(direction Direction) error {
+	stream := &e.streams[direction]
+	if len(stream.malformed) == 0 {
+		return nil
+	}
+
+	comment := fmt.Sprintf("malformed: %s (%d bytes)", stream.reason, len(stream.malformed))
+	err := e.Writer.WritePacket(stream.timestamp, direction, stream.malformed, comment)
+	stream.malformed = nil
+	return err
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// CRSF has no link type of its own, frames are written as LINKTYPE_USER0.
+// In Wireshark, map DLT User 0 (147) to the crsf dissector (Preferences > Protocols > DLT_USER).
+const PcapngLinkType = 147
+
+const PcapngFileExtension = ".pcapng"
+
+const (
+	pcapngSectionHeaderBlock   = 0x0A0D0D0A
+	pcapngInterfaceBlock       = 0x00000001
+	pcapngEnhancedPacketBlock  = 0x00000006
+	pcapngByteOrderMagic       = 0x1A2B3C4D
+	pcapngOptionEnd            = 0
+	pcapngOptionComment        = 1
+	pcapngOptionShbUserAppl    = 4
+	pcapngOptionIfName         = 2
+	pcapngOptionIfTsResol      = 9
+	pcapngOptionEpbFlags       = 2
+	pcapngEpbFlagsInbound      = 0x01
+	pcapngEpbFlagsOutbound     = 0x02
+	pcapngTimestampNanoseconds = 9
+)
+
+// PcapngWriter writes CRSF frames as packets of a single pcapng interface
+type PcapngWriter struct {
+	writer *bufio.Writer
+}
+
+func NewPcapngWriter(w io.Writer) (*PcapngWriter, error) {
+	p := &PcapngWriter{writer: bufio.NewWriter(w)}
+
+	//section header
+	var body []byte
+	body = binary.LittleEndian.AppendUint32(body, pcapngByteOrderMagic)
+	body = binary.LittleEndian.AppendUint16(body, 1) //major version
+	body = binary.LittleEndian.AppendUint16(body, 0) //minor version
+	body = binary.LittleEndian.AppendUint64(body, 0xFFFFFFFFFFFFFFFF)
+	body = appendPcapngOption(body, pcapngOptionShbUserAppl, []byte("elrs-control"))
+	body = appendPcapngOption(body, pcapngOptionEnd, nil)
+	if err := p.writeBlock(pcapngSectionHeaderBlock, body); err != nil {
+		return nil, err
+	}
+
+	//interface description, with nanosecond timestamps
+	body = nil
+	body = binary.LittleEndian.AppendUint16(body, PcapngLinkType)
+	body = binary.LittleEndian.AppendUint16(body, 0) //reserved
+	body = binary.LittleEndian.AppendUint32(body, 0) //no snap length
+	body = appendPcapngOption(body, pcapngOptionIfName, []byte("crsf"))
+	body = appendPcapngOption(body, pcapngOptionIfTsResol, []byte{pcapngTimestampNanoseconds})
+	body = appendPcapngOption(body, pcapngOptionEnd, nil)
+	if err := p.writeBlock(pcapngInterfaceBlock, body); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// WritePacket writes one frame, the comment is shown by Wireshark as the packet's annotation
+func (p *PcapngWriter) WritePacket(timestamp time.Time, direction Direction, data []byte, comment string) error {
+	ts := uint64(timestamp.UnixNano())
+
+	flags := uint32(pcapngEpbFlagsInbound)
+	if direction == DirectionTx {
+		flags = pcapngEpbFlagsOutbound
+	}
+
+	var body []byte
+	body = binary.LittleEndian.AppendUint32(body, 0) //interface id
+	body = binary.LittleEndian.AppendUint32(body, uint32(ts>>32))
+	body = binary.LittleEndian.AppendUint32(body, uint32(ts))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data)))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data)))
+	body = append(body, data...)
+	body = append(body, make([]byte, pcapngPadding(len(data)))...)
+	body = appendPcapngOption(body, pcapngOptionEpbFlags, binary.LittleEndian.AppendUint32(nil, flags))
+	if comment != "" {
+		body = appendPcapngOption(body, pcapngOptionComment, []byte(comment))
+	}
+	body = appendPcapngOption(body, pcapngOptionEnd, nil)
+
+	return p.writeBlock(pcapngEnhancedPacketBlock, body)
+}
+
+func (p *PcapngWriter) Flush() error {
+	return p.writer.Flush()
+}
+
+func (p *PcapngWriter) writeBlock(blockType uint32, body []byte) error {
+	totalLength := uint32(12 + len(body))
+
+	var block []byte
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, totalLength)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, totalLength)
+
+	_, err := p.writer.Write(block)
+	return err
+}
+
+func appendPcapngOption(body []byte, code uint16, value []byte) []byte {
+	body = binary.LittleEndian.AppendUint16(body, code)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(value)))
+	body = append(body, value...)
+	return append(body, make([]byte, pcapngPadding(len(value)))...)
+}
+
+func pcapngPadding(length int) int {
+	return (4 - length%4) % 4
+}
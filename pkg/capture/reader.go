@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+type Chunk struct {
+	Direction Direction
+	Offset    time.Duration //since the start of the capture file
+	Time      time.Time
+	Data      []byte
+}
+
+func (c *Chunk) String() string {
+	return fmt.Sprintf("(chunk) dir: %s, offset: %v, data: %x", c.Direction, c.Offset, c.Data)
+}
+
+// Reader reads the chunks of a capture file, in the order they were recorded
+type Reader struct {
+	Start time.Time
+
+	reader *bufio.Reader
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := bufio.NewReader(r)
+
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	if [8]byte(header[0:8]) != Magic {
+		return nil, errors.New("not a crsf capture file")
+	}
+
+	return &Reader{
+		Start:  time.Unix(0, int64(binary.BigEndian.Uint64(header[8:16]))),
+		reader: reader,
+	}, nil
+}
+
+// Next returns the next chunk, or io.EOF once the capture is exhausted
+func (r *Reader) Next() (*Chunk, error) {
+	direction, err := r.reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	length, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if length > MaxRecordSize {
+		return nil, errors.New(fmt.Sprintf("capture record of %d bytes exceeds the maximum of %d bytes", length, MaxRecordSize))
+	}
+
+	data := make([]byte, length)
+	if _, err = io.ReadFull(r.reader, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return &Chunk{
+		Direction: Direction(direction),
+		Offset:    time.Duration(offset),
+		Time:      r.Start.Add(time.Duration(offset)),
+		Data:      data,
+	}, nil
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder writes raw port traffic to capture files.
+// When MaxSize or MaxDuration are set, a new file is started once the current one reaches the limit,
+// the files are numbered path-000.crsfcap, path-001.crsfcap, and so on.
+type Recorder struct {
+	Path        string
+	MaxSize     int64
+	MaxDuration time.Duration
+
+	mutex     sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	fileStart time.Time
+	fileSize  int64
+	fileIndex int
+	lastFlush time.Time
+}
+
+func NewRecorder(path string, maxSize int64, maxDuration time.Duration) *Recorder {
+	return &Recorder{
+		Path:        path,
+		MaxSize:     maxSize,
+		MaxDuration: maxDuration,
+	}
+}
+
+func (r *Recorder) rotates() bool {
+	return r.MaxSize > 0 || r.MaxDuration > 0
+}
+
+// FileName returns the name of the n-th capture file
+func (r *Recorder) FileName(index int) string {
+	if !r.rotates() {
+		return r.Path
+	}
+
+	ext := filepath.Ext(r.Path)
+	if ext == "" {
+		ext = FileExtension
+	}
+	return fmt.Sprintf("%s-%03d%s", strings.TrimSuffix(r.Path, filepath.Ext(r.Path)), index, ext)
+}
+
+func (r *Recorder) open(now time.Time) error {
+	name := r.FileName(r.fileIndex)
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	r.file = file
+	r.writer = bufio.NewWriter(file)
+	r.fileStart = now
+	r.lastFlush = r.fileStart
+	r.fileIndex += 1
+
+	var header [HeaderSize]byte
+	copy(header[0:8], Magic[:])
+	binary.BigEndian.PutUint64(header[8:16], uint64(r.fileStart.UnixNano()))
+
+	written, err := r.writer.Write(header[:])
+	r.fileSize = int64(written)
+	return err
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	flushErr := r.writer.Flush()
+	closeErr := r.file.Close()
+	r.file = nil
+	r.writer = nil
+
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// Record appends a chunk of port traffic to the current capture file
+func (r *Recorder) Record(direction Direction, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+
+	var record [1 + 2*binary.MaxVarintLen64]byte
+	recordSize := int64(1 + binary.MaxVarintLen64*2 + len(data))
+
+	if r.file != nil && r.fileSize > HeaderSize {
+		if (r.MaxSize > 0 && r.fileSize+recordSize > r.MaxSize) || (r.MaxDuration > 0 && now.Sub(r.fileStart) >= r.MaxDuration) {
+			if err := r.closeFile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.file == nil {
+		if err := r.open(now); err != nil {
+			return err
+		}
+	}
+
+	record[0] = uint8(direction)
+	length := 1
+	length += binary.PutUvarint(record[length:], uint64(now.Sub(r.fileStart)))
+	length += binary.PutUvarint(record[length:], uint64(len(data)))
+
+	if _, err := r.writer.Write(record[:length]); err != nil {
+		return err
+	}
+	if _, err := r.writer.Write(data); err != nil {
+		return err
+	}
+	r.fileSize += int64(length + len(data))
+
+	if now.Sub(r.lastFlush) >= flushInterval {
+		r.lastFlush = now
+		return r.writer.Flush()
+	}
+
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.closeFile()
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const ReplayScheme = "replay://"
+
+// ReplayTransport plays back the received (rx) side of a capture, as if a TX module was sending it.
+// Everything written to it is discarded.
+//
+// Speed 1 replays at the original pace, 2 twice as fast, and 0 as fast as possible.
+// In stepwise mode, each call to Step releases the next chunk.
+type ReplayTransport struct {
+	Name        string
+	BaudRate    int32
+	ReadTimeout time.Duration
+	Speed       float64
+	Stepwise    bool
+	Loop        bool
+
+	open func() (io.ReadCloser, error)
+
+	file     io.ReadCloser
+	reader   *Reader
+	next     *Chunk
+	pending  []byte
+	start    time.Time
+	base     time.Duration
+	finished bool
+	stepChan chan any
+	doneChan chan any
+}
+
+// NewReplayFile replays a capture file
+func NewReplayFile(path string, baudRate int32) *ReplayTransport {
+	return newReplayTransport(path, baudRate, func() (io.ReadCloser, error) {
+		return os.Open(path)
+	})
+}
+
+// NewReplayBytes replays a capture held in memory, e.g. a test fixture
+func NewReplayBytes(name string, data []byte, baudRate int32) *ReplayTransport {
+	return newReplayTransport(name, baudRate, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// IsReplayName checks if a port name refers to a capture file, i.e. replay://path/to/file.crsfcap
+func IsReplayName(name string) bool {
+	return strings.HasPrefix(name, ReplayScheme)
+}
+
+func NewReplayFromName(name string, baudRate int32) *ReplayTransport {
+	return NewReplayFile(strings.TrimPrefix(name, ReplayScheme), baudRate)
+}
+
+func newReplayTransport(name string, baudRate int32, open func() (io.ReadCloser, error)) *ReplayTransport {
+	return &ReplayTransport{
+		Name:     name,
+		BaudRate: baudRate,
+		Speed:    1,
+		open:     open,
+		stepChan: make(chan any),
+		doneChan: make(chan any),
+	}
+}
+
+func (t *ReplayTransport) Open() error {
+	if t.finished && !t.Loop {
+		return errors.New(fmt.Sprintf("replay of %s is finished", t.Name))
+	}
+
+	file, err := t.open()
+	if err != nil {
+		return err
+	}
+
+	reader, err := NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	t.file = file
+	t.reader = reader
+	t.next = nil
+	t.pending = nil
+	t.start = time.Now()
+	t.base = -1
+
+	return nil
+}
+
+func (t *ReplayTransport) Close() error {
+	if t.file == nil {
+		return nil
+	}
+
+	err := t.file.Close()
+	t.file = nil
+	t.reader = nil
+	return err
+}
+
+func (t *ReplayTransport) SetReadTimeout(duration time.Duration) error {
+	t.ReadTimeout = duration
+	return nil
+}
+
+// SetBaudRate fails for any rate but the current one, a capture was recorded at a fixed rate
+func (t *ReplayTransport) SetBaudRate(baudRate int32) error {
+	if baudRate != t.BaudRate {
+		return errors.New(fmt.Sprintf("cannot change the baud rate of replay %s", t.Name))
+	}
+	return nil
+}
+
+func (t *ReplayTransport) PortName() string {
+	return ReplayScheme + t.Name
+}
+
+func (t *ReplayTransport) PortBaudRate() int32 {
+	return t.BaudRate
+}
+
+// Step releases the next chunk in stepwise mode, it blocks until a Read picks it up
+func (t *ReplayTransport) Step() {
+	t.stepChan <- nil
+}
+
+// Done is closed once the whole capture was replayed
+func (t *ReplayTransport) Done() <-chan any {
+	return t.doneChan
+}
+
+func (t *ReplayTransport) Write(bytes []byte) (int, error) {
+	if t.file == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", t.PortName()))
+	}
+	return len(bytes), nil
+}
+
+// Read returns the next received chunk once it is due, or (0, nil) when the read timeout expires first
+func (t *ReplayTransport) Read(bytes []byte) (int, error) {
+	if t.reader == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", t.PortName()))
+	}
+
+	if len(t.pending) > 0 {
+		count := copy(bytes, t.pending)
+		t.pending = t.pending[count:]
+		return count, nil
+	}
+
+	if t.next == nil {
+		chunk, err := t.nextRx()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				t.finish()
+			}
+			return 0, err
+		}
+		t.next = chunk
+	}
+
+	var timeout <-chan time.Time
+	if t.ReadTimeout > 0 {
+		timeout = time.After(t.ReadTimeout)
+	}
+
+	if t.Stepwise {
+		select {
+		case <-t.stepChan:
+		case <-timeout:
+			return 0, nil
+		}
+	} else if t.Speed > 0 {
+		due := t.start.Add(time.Duration(float64(t.next.Offset-t.base) / t.Speed))
+		if wait := time.Until(due); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-timeout:
+				return 0, nil
+			}
+		}
+	}
+
+	t.pending = t.next.Data
+	t.next = nil
+
+	count := copy(bytes, t.pending)
+	t.pending = t.pending[count:]
+	return count, nil
+}
+
+// nextRx skips the chunks that were written to the port
+func (t *ReplayTransport) nextRx() (*Chunk, error) {
+	for {
+		chunk, err := t.reader.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.Direction != DirectionRx {
+			continue
+		}
+
+		if t.base < 0 {
+			t.base = chunk.Offset
+		}
+		return chunk, nil
+	}
+}
+
+func (t *ReplayTransport) finish() {
+	if t.finished {
+		return
+	}
+
+	fmt.Printf("(replay) %s finished\n", t.Name)
+	t.finished = true
+	close(t.doneChan)
+}
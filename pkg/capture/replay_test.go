@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"errors"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"golang.org/x/exp/slices"
+	"gopkg.in/tomb.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayThroughTelemetryReader(t *testing.T) {
+	battery := (&crsf.Battery{
+		Addr:      crsf.HandsetEndpoint,
+		Voltage:   168,
+		Current:   42,
+		Capacity:  1300,
+		Remaining: 75,
+	}).Marshal()
+	linkStats := (&crsf.LinkStats{
+		Addr:         crsf.HandsetEndpoint,
+		UplinkRSSI1:  -60,
+		UplinkRSSI2:  -70,
+		UplinkLQ:     100,
+		UplinkSNR:    9,
+		RFMode:       5,
+		UplinkPower:  2,
+		DownlinkRSSI: -55,
+		DownlinkLQ:   98,
+		DownlinkSNR:  7,
+	}).Marshal()
+	channels := (&crsf.Channels{Addr: crsf.ModuleEndpoint}).Marshal()
+
+	path := filepath.Join(t.TempDir(), "replay.crsfcap")
+	recorder := NewRecorder(path, 0, 0)
+	record := func(direction Direction, data []byte) {
+		if err := recorder.Record(direction, data); err != nil {
+			t.Fatalf("recording: %v", err)
+		}
+	}
+
+	//the battery frame is split across two reads, the channels frame was written to the port and is not replayed
+	record(DirectionRx, battery[:5])
+	record(DirectionRx, battery[5:])
+	record(DirectionTx, channels)
+	record(DirectionRx, append([]byte{0x00, 0x13}, linkStats...))
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("closing recorder: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading capture: %v", err)
+	}
+
+	replay := NewReplayBytes("test", data, 921600)
+	replay.Speed = 0
+	if err := replay.Open(); err != nil {
+		t.Fatalf("opening replay: %v", err)
+	}
+	defer func() { _ = replay.Close() }()
+
+	readerTomb := &tomb.Tomb{}
+	reader := telem.NewReader(replay)
+
+	//a frame holds on to the buffer of the reader, it is checked before the next one is read
+	var types []crsf.FrameType
+	for {
+		frame, err := reader.Next(readerTomb)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading frame %d: %v", len(types), err)
+		}
+		types = append(types, frame.Type())
+
+		switch tFrame := frame.(type) {
+		case telem.TelemBatteryType:
+			if tFrame.Voltage() != 16.8 || tFrame.Current() != 4.2 || tFrame.Fuel() != 1300 || tFrame.Remaining() != 75 {
+				t.Errorf("battery frame: %v", tFrame)
+			}
+		case *telem.LinkStatsFrame:
+			if tFrame.UplinkRSSI1() != -60 || tFrame.UplinkLinkQuality() != 100 || tFrame.DownlinkSNR() != 7 {
+				t.Errorf("link stats frame: %v", tFrame)
+			}
+		default:
+			t.Errorf("unexpected frame %d: %v", len(types)-1, frame)
+		}
+	}
+
+	if want := []crsf.FrameType{crsf.BatteryFrame, crsf.LinkStatsFrame}; !slices.Equal(types, want) {
+		t.Errorf("got frames %v, want %v", types, want)
+	}
+
+	select {
+	case <-replay.Done():
+	default:
+		t.Errorf("replay is not done after the last chunk")
+	}
+}
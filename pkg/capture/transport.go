@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package capture
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/serial"
+)
+
+// RecordingTransport passes everything through to the wrapped transport, and records every byte read and written
+type RecordingTransport struct {
+	serial.Transport
+	Recorder *Recorder
+}
+
+func NewRecordingTransport(transport serial.Transport, recorder *Recorder) *RecordingTransport {
+	return &RecordingTransport{
+		Transport: transport,
+		Recorder:  recorder,
+	}
+}
+
+func (t *RecordingTransport) Read(bytes []byte) (int, error) {
+	count, err := t.Transport.Read(bytes)
+	if count > 0 {
+		t.record(DirectionRx, bytes[:count])
+	}
+	return count, err
+}
+
+func (t *RecordingTransport) Write(bytes []byte) (int, error) {
+	count, err := t.Transport.Write(bytes)
+	if count > 0 {
+		t.record(DirectionTx, bytes[:count])
+	}
+	return count, err
+}
+
+func (t *RecordingTransport) record(direction Direction, data []byte) {
+	if err := t.Recorder.Record(direction, data); err != nil {
+		fmt.Printf("(capture) error recording %s data on port %s. %s\n", direction, t.PortName(), err.Error())
+	}
+}
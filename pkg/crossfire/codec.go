@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crc"
+)
+
+// MaxFrameSize is the largest CRSF frame, including address, length and crc
+const MaxFrameSize = 64
+
+// Frame is a CRSF frame that can be encoded to, and decoded from, its wire format.
+// Marshal returns the complete frame (address, length, type, payload, crc),
+// Unmarshal accepts the same, regardless of the address the frame was sent to.
+type Frame interface {
+	Type() FrameType
+	Marshal() []byte
+	Unmarshal(data []byte) error
+}
+
+// UnmarshalFrame decodes a complete frame into the codec type matching its frame type
+func UnmarshalFrame(data []byte) (Frame, error) {
+	if len(data) < 4 {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as frame. length is too small", data))
+	}
+
+	var frame Frame
+	switch FrameType(data[2]) {
+	case GpsFrame:
+		frame = &GPS{}
+	case GpsTimeFrame:
+		frame = &GPSTime{}
+	case GpsExtendedFrame:
+		frame = &GPSExtended{}
+	case VarioFrame:
+		frame = &Variometer{}
+	case BatteryFrame:
+		frame = &Battery{}
+	case BaroAltFrame:
+		if len(data) == barometerFrameSize {
+			frame = &Barometer{}
+		} else {
+			frame = &BarometerVariometer{}
+		}
+	case AirspeedFrame:
+		frame = &Airspeed{}
+	case HeartbeatFrame:
+		frame = &Heartbeat{}
+	case RpmFrame:
+		frame = &RPM{}
+	case TemperatureFrame:
+		frame = &Temperature{}
+	case CellsFrame:
+		frame = &Cells{}
+	case LinkStatsFrame:
+		frame = &LinkStats{}
+	case ChannelsFrame:
+		frame = &Channels{}
+	case SubsetChannelsFrame:
+		frame = &SubsetChannels{}
+	case AltitudeFrame:
+		frame = &Attitude{}
+	case FlightModeFrame:
+		frame = &FlightMode{}
+	case PingDevicesFrame:
+		frame = &PingDevices{}
+	case DeviceInfoFrame:
+		frame = &DeviceInfo{}
+	case ParameterSettingsEntryFrame:
+		frame = &ParameterEntry{}
+	case ParameterSettingsReadFrame:
+		frame = &ParameterRead{}
+	case ParameterSettingsWriteFrame:
+		frame = &ParameterWrite{}
+	case StatusFrame:
+		frame = &Status{}
+	case CommandFrame:
+		frame = &Command{}
+	case RadioFrame:
+		frame = &OpenTxSync{}
+	case MspRequestFrame, MspResponseFrame, MspWriteFrame:
+		frame = &MSP{}
+	case DisplayPortFrame:
+		frame = &DisplayPort{}
+	case ArduPilotPassthroughFrame:
+		frame = &Passthrough{}
+	case MavlinkEnvelopeFrame:
+		frame = &MavlinkEnvelope{}
+	default:
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as frame. unknown frame type %x", data, data[2]))
+	}
+
+	if err := frame.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func marshalFrame(addr Endpoint, fType FrameType, payload []byte) []byte {
+	frame := make([]byte, 0, len(payload)+4)
+	frame = append(frame, uint8(addr), uint8(len(payload)+2), uint8(fType))
+	frame = append(frame, payload...)
+	return append(frame, crc.D5(frame[2:]))
+}
+
+func marshalExtFrame(addr Endpoint, fType FrameType, dst Endpoint, src Endpoint, payload []byte) []byte {
+	extPayload := make([]byte, 0, len(payload)+2)
+	extPayload = append(extPayload, uint8(dst), uint8(src))
+	return marshalFrame(addr, fType, append(extPayload, payload...))
+}
+
+// unmarshalFrame checks the frame's length, type and crc, and returns the address and payload
+func unmarshalFrame(data []byte, fType FrameType, minPayload int) (Endpoint, []byte, error) {
+	if len(data) < 4 {
+		return 0, nil, errors.New(fmt.Sprintf("cannot unmarshal %x as %x frame. length is too small", data, fType))
+	}
+
+	if int(data[1])+2 != len(data) {
+		return 0, nil, errors.New(fmt.Sprintf("cannot unmarshal %x as %x frame. length byte is %d, but frame has %d bytes", data, fType, data[1], len(data)))
+	}
+
+	if FrameType(data[2]) != fType {
+		return 0, nil, errors.New(fmt.Sprintf("cannot unmarshal %x as %x frame. frame type is %x", data, fType, data[2]))
+	}
+
+	if crc.D5(data[2:len(data)-1]) != data[len(data)-1] {
+		return 0, nil, errors.New(fmt.Sprintf("cannot unmarshal %x as %x frame. crc mismatch", data, fType))
+	}
+
+	payload := data[3 : len(data)-1]
+	if len(payload) < minPayload {
+		return 0, nil, errors.New(fmt.Sprintf("cannot unmarshal %x as %x frame. expected at least %d payload bytes, but got %d", data, fType, minPayload, len(payload)))
+	}
+
+	return Endpoint(data[0]), payload, nil
+}
+
+// unmarshalExtFrame works like unmarshalFrame, and splits off the destination and source of extended frames
+func unmarshalExtFrame(data []byte, fType FrameType, minPayload int) (Endpoint, Endpoint, Endpoint, []byte, error) {
+	addr, payload, err := unmarshalFrame(data, fType, minPayload+2)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	return addr, Endpoint(payload[0]), Endpoint(payload[1]), payload[2:], nil
+}
+
+// cString reads a null terminated string, and returns the bytes after it
+func cString(data []byte) (string, []byte, error) {
+	for i, c := range data {
+		if c == 0 {
+			return string(data[:i]), data[i+1:], nil
+		}
+	}
+	return "", nil, errors.New(fmt.Sprintf("string %x is not null terminated", data))
+}
+
+func appendCString(data []byte, value string) []byte {
+	data = append(data, value...)
+	return append(data, 0)
+}
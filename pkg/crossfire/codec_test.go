@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"bytes"
+	"github.com/kaack/elrs-joystick-control/pkg/crc"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"reflect"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame Frame
+	}{
+		{"gps", &GPS{Addr: HandsetEndpoint, Latitude: 473977418, Longitude: -85455938, GroundSpeed: 1250, Heading: 27000, Altitude: 1420, Satellites: 12}},
+		{"battery", &Battery{Addr: HandsetEndpoint, Voltage: 168, Current: 42, Capacity: 0x123456, Remaining: 75}},
+		{"link stats", &LinkStats{Addr: HandsetEndpoint, UplinkRSSI1: -60, UplinkRSSI2: -70, UplinkLQ: 100, UplinkSNR: 9, ActiveAntenna: 1, RFMode: 5, UplinkPower: 2, DownlinkRSSI: -55, DownlinkLQ: 98, DownlinkSNR: -3}},
+		{"attitude", &Attitude{Addr: HandsetEndpoint, Pitch: -1200, Roll: 3400, Yaw: -31000}},
+		{"flight mode", &FlightMode{Addr: HandsetEndpoint, Mode: "ANGL*"}},
+		{"barometer", &Barometer{Addr: HandsetEndpoint, Altitude: 10123, VerticalSpeed: -12}},
+		{"barometer variometer", &BarometerVariometer{Addr: HandsetEndpoint, Altitude: 10123, VerticalSpeed: -250}},
+		{"variometer", &Variometer{Addr: HandsetEndpoint, VerticalSpeed: 321}},
+		{"device info", &DeviceInfo{Addr: HandsetEndpoint, Dst: LuaEndpoint, Src: ModuleEndpoint, Name: "ELRS TX", SerialNumber: 0x454c5253, HardwareVersion: 1, SoftwareVersion: 0x030400, FieldCount: 24, ParameterVersion: 0}},
+		{"parameter entry", &ParameterEntry{Addr: HandsetEndpoint, Dst: LuaEndpoint, Src: ModuleEndpoint, FieldId: 3, ChunksRemaining: 1, Chunk: []byte{0, 9, 'P', 'a', 'c', 'k', 'e', 't', 0}}},
+		{"parameter read", &ParameterRead{Addr: FlightControllerEndpoint, Dst: ModuleEndpoint, Src: LuaEndpoint, FieldId: 3, ChunkIndex: 1}},
+		{"parameter write", &ParameterWrite{Addr: FlightControllerEndpoint, Dst: ModuleEndpoint, Src: LuaEndpoint, FieldId: 7, Value: []byte{0x12, 0x34}}},
+		{"status", &Status{Addr: HandsetEndpoint, Dst: LuaEndpoint, Src: ModuleEndpoint, BadPackets: 2, GoodPackets: 500, Flags: 0x01, Message: "armed"}},
+		{"sync", &OpenTxSync{Addr: HandsetEndpoint, Dst: HandsetEndpoint, Src: ModuleEndpoint, Rate: 40000, Offset: -1234}},
+		{"channels", &Channels{Addr: ModuleEndpoint, Values: [16]util.CRSFValue{172, 992, 1811, 0, 2047, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}},
+		{"subset channels", &SubsetChannels{Addr: ModuleEndpoint, StartChannel: 4, Resolution: Resolution11Bit, Values: []util.CRSFValue{0, 1024, 2047, 992}}},
+		{"command", NewModelSelectCommand(3)},
+		{"speed proposal", NewSpeedProposalCommand(0, 921600)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := test.frame.Marshal()
+
+			if int(data[1])+2 != len(data) {
+				t.Fatalf("length byte is %d, but frame has %d bytes: %x", data[1], len(data), data)
+			}
+			if FrameType(data[2]) != test.frame.Type() {
+				t.Fatalf("frame type is %x, want %x", data[2], test.frame.Type())
+			}
+			if want := crc.D5(data[2 : len(data)-1]); data[len(data)-1] != want {
+				t.Errorf("crc is %x, want %x: %x", data[len(data)-1], want, data)
+			}
+			if test.frame.Type() == CommandFrame {
+				if want := crc.BA(data[2 : len(data)-2]); data[len(data)-2] != want {
+					t.Errorf("crc BA is %x, want %x: %x", data[len(data)-2], want, data)
+				}
+			}
+
+			decoded, err := UnmarshalFrame(data)
+			if err != nil {
+				t.Fatalf("unmarshal %x: %v", data, err)
+			}
+			if !reflect.DeepEqual(decoded, test.frame) {
+				t.Errorf("got %+v, want %+v", decoded, test.frame)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFrameRejectsBadCrc(t *testing.T) {
+	for _, frame := range []Frame{
+		&Battery{Addr: HandsetEndpoint, Voltage: 168},
+		NewModelSelectCommand(3),
+	} {
+		data := frame.Marshal()
+		data[len(data)-1] ^= 0xFF
+		if _, err := UnmarshalFrame(data); err == nil {
+			t.Errorf("crc mismatch of %x is not detected", data)
+		}
+	}
+
+	//a command whose BA crc is wrong, but whose D5 crc matches the corrupted frame
+	data := NewModelSelectCommand(3).Marshal()
+	data[len(data)-2] ^= 0xFF
+	data[len(data)-1] = crc.D5(data[2 : len(data)-1])
+	if _, err := UnmarshalFrame(data); err == nil {
+		t.Errorf("crc BA mismatch of %x is not detected", data)
+	}
+}
+
+func TestCreateFrames(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{"ping devices", CreatePingDevicesFrame(), []byte{0xC8, 0x04, 0x28, 0x00, 0xEF, 0x7F}},
+		{"parameter read", CreateParameterSettingsReadFrame(0xEE, 5, 1), []byte{0xC8, 0x06, 0x2C, 0xEE, 0xEF, 0x05, 0x01, 0x8F}},
+		{"parameter write uint8", CreateParameterSettingWriteFrameUint8(0xEE, 7, 3), []byte{0xC8, 0x06, 0x2D, 0xEE, 0xEF, 0x07, 0x03, 0x50}},
+		{"parameter write uint16", CreateParameterSettingWriteFrameUint16(0xEC, 9, 0x1234), []byte{0xC8, 0x07, 0x2D, 0xEC, 0xEF, 0x09, 0x12, 0x34, 0x83}},
+		{"model id", CreateModelIDFrame(3), []byte{0xC8, 0x08, 0x32, 0xEE, 0xEA, 0x10, 0x05, 0x03, 0x13, 0x5F}},
+	}
+
+	for _, test := range tests {
+		if !bytes.Equal(test.got, test.want) {
+			t.Errorf("%s: got % X, want % X", test.name, test.got, test.want)
+		}
+	}
+}
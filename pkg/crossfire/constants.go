@@ -31,11 +31,19 @@ const (
 
 const (
 	GpsFrame                    FrameType = 0x02
+	GpsTimeFrame                FrameType = 0x03
+	GpsExtendedFrame            FrameType = 0x06
 	VarioFrame                  FrameType = 0x07
 	BatteryFrame                FrameType = 0x08
 	BaroAltFrame                FrameType = 0x09
+	AirspeedFrame               FrameType = 0x0A
+	HeartbeatFrame              FrameType = 0x0B
+	RpmFrame                    FrameType = 0x0C
+	TemperatureFrame            FrameType = 0x0D
+	CellsFrame                  FrameType = 0x0E
 	LinkStatsFrame              FrameType = 0x14
 	ChannelsFrame               FrameType = 0x16
+	SubsetChannelsFrame         FrameType = 0x17
 	LinkRxFrame                 FrameType = 0x1C
 	LinkTxFrame                 FrameType = 0x1D
 	AltitudeFrame               FrameType = 0x1E
@@ -49,10 +57,19 @@ const (
 	StatusFrame                 FrameType = 0x2E
 	CommandFrame                FrameType = 0x32
 	RadioFrame                  FrameType = 0x3A
+	MspRequestFrame             FrameType = 0x7A
+	MspResponseFrame            FrameType = 0x7B
+	MspWriteFrame               FrameType = 0x7C
+	DisplayPortFrame            FrameType = 0x7D
+	ArduPilotPassthroughFrame   FrameType = 0x80
+	MavlinkEnvelopeFrame        FrameType = 0xAA
 	UartSyncFrame               FrameType = 0xC8
 	SubcommandFrame             FrameType = 0x10
 	CmdModelSelectFrame         FrameType = 0x05
 	OpenTxSyncFrame             FrameType = 0x10
+	GeneralSubcommandFrame      FrameType = 0x0A
+	CmdSpeedProposalFrame       FrameType = 0x70
+	CmdSpeedResponseFrame       FrameType = 0x71
 )
 
 //goland:noinspection GoUnusedExportedFunction
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// Airspeed holds the wire values of an airspeed frame
+type Airspeed struct {
+	Addr  Endpoint
+	Speed uint16 // km/h * 10
+}
+
+func (f *Airspeed) Type() FrameType {
+	return AirspeedFrame
+}
+
+func (f *Airspeed) Marshal() []byte {
+	return marshalFrame(f.Addr, AirspeedFrame, binary.BigEndian.AppendUint16(nil, f.Speed))
+}
+
+func (f *Airspeed) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, AirspeedFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Speed = binary.BigEndian.Uint16(payload[0:2])
+	return nil
+}
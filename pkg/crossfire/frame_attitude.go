@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// Attitude holds the wire values of an attitude frame, angles are in radians * 10000
+type Attitude struct {
+	Addr  Endpoint
+	Pitch int16
+	Roll  int16
+	Yaw   int16
+}
+
+func (f *Attitude) Type() FrameType {
+	return AltitudeFrame
+}
+
+func (f *Attitude) Marshal() []byte {
+	payload := make([]byte, 0, 6)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(f.Pitch))
+	payload = binary.BigEndian.AppendUint16(payload, uint16(f.Roll))
+	payload = binary.BigEndian.AppendUint16(payload, uint16(f.Yaw))
+	return marshalFrame(f.Addr, AltitudeFrame, payload)
+}
+
+func (f *Attitude) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, AltitudeFrame, 6)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Pitch = int16(binary.BigEndian.Uint16(payload[0:2]))
+	f.Roll = int16(binary.BigEndian.Uint16(payload[2:4]))
+	f.Yaw = int16(binary.BigEndian.Uint16(payload[4:6]))
+	return nil
+}
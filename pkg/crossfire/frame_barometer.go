@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// barometerFrameSize tells the barometer frame apart from the barometer-variometer frame, both share the same type
+const barometerFrameSize = 7
+
+// Barometer holds the wire values of the packed barometric altitude frame
+type Barometer struct {
+	Addr Endpoint
+	// Altitude is in decimeters + 10000, or in meters when the high bit is set
+	Altitude uint16
+	// VerticalSpeed is packed (logarithmic), as sent by Betaflight
+	VerticalSpeed int8
+}
+
+func (f *Barometer) Type() FrameType {
+	return BaroAltFrame
+}
+
+func (f *Barometer) Marshal() []byte {
+	payload := make([]byte, 0, 3)
+	payload = binary.BigEndian.AppendUint16(payload, f.Altitude)
+	payload = append(payload, uint8(f.VerticalSpeed))
+	return marshalFrame(f.Addr, BaroAltFrame, payload)
+}
+
+func (f *Barometer) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, BaroAltFrame, 3)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Altitude = binary.BigEndian.Uint16(payload[0:2])
+	f.VerticalSpeed = int8(payload[2])
+	return nil
+}
+
+// BarometerVariometer holds the wire values of the combined barometric altitude and vertical speed frame
+type BarometerVariometer struct {
+	Addr Endpoint
+	// Altitude is in decimeters + 10000, or in meters when the high bit is set
+	Altitude      uint16
+	VerticalSpeed int16 // cm/s
+}
+
+func (f *BarometerVariometer) Type() FrameType {
+	return BaroAltFrame
+}
+
+func (f *BarometerVariometer) Marshal() []byte {
+	payload := make([]byte, 0, 4)
+	payload = binary.BigEndian.AppendUint16(payload, f.Altitude)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(f.VerticalSpeed))
+	return marshalFrame(f.Addr, BaroAltFrame, payload)
+}
+
+func (f *BarometerVariometer) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, BaroAltFrame, 4)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Altitude = binary.BigEndian.Uint16(payload[0:2])
+	f.VerticalSpeed = int16(binary.BigEndian.Uint16(payload[2:4]))
+	return nil
+}
+
+// Variometer holds the wire values of the vertical speed frame
+type Variometer struct {
+	Addr          Endpoint
+	VerticalSpeed int16 // cm/s
+}
+
+func (f *Variometer) Type() FrameType {
+	return VarioFrame
+}
+
+func (f *Variometer) Marshal() []byte {
+	return marshalFrame(f.Addr, VarioFrame, binary.BigEndian.AppendUint16(nil, uint16(f.VerticalSpeed)))
+}
+
+func (f *Variometer) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, VarioFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.VerticalSpeed = int16(binary.BigEndian.Uint16(payload[0:2]))
+	return nil
+}
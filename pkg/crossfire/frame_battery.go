@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// Battery holds the wire values of a battery sensor frame
+type Battery struct {
+	Addr      Endpoint
+	Voltage   uint16 // volts * 10
+	Current   uint16 // amps * 10
+	Capacity  uint32 // mAh, only the lower 24 bits are sent
+	Remaining uint8  // percent
+}
+
+func (f *Battery) Type() FrameType {
+	return BatteryFrame
+}
+
+func (f *Battery) Marshal() []byte {
+	payload := make([]byte, 0, 8)
+	payload = binary.BigEndian.AppendUint16(payload, f.Voltage)
+	payload = binary.BigEndian.AppendUint16(payload, f.Current)
+	payload = append(payload, uint8(f.Capacity>>16), uint8(f.Capacity>>8), uint8(f.Capacity))
+	payload = append(payload, f.Remaining)
+	return marshalFrame(f.Addr, BatteryFrame, payload)
+}
+
+func (f *Battery) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, BatteryFrame, 8)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Voltage = binary.BigEndian.Uint16(payload[0:2])
+	f.Current = binary.BigEndian.Uint16(payload[2:4])
+	f.Capacity = binary.BigEndian.Uint32([]byte{0, payload[4], payload[5], payload[6]})
+	f.Remaining = payload[7]
+	return nil
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MaxCellValues the cell voltages that fit into a cells frame
+const MaxCellValues = 29
+
+// Cells holds the wire values of a cells frame, the voltages of the individual battery cells
+type Cells struct {
+	Addr     Endpoint
+	SourceId uint8
+	Voltages []uint16 // millivolts
+}
+
+func (f *Cells) Type() FrameType {
+	return CellsFrame
+}
+
+func (f *Cells) Marshal() []byte {
+	voltages := f.Voltages[:min(len(f.Voltages), MaxCellValues)]
+	payload := make([]byte, 0, 1+2*len(voltages))
+	payload = append(payload, f.SourceId)
+	for _, voltage := range voltages {
+		payload = binary.BigEndian.AppendUint16(payload, voltage)
+	}
+	return marshalFrame(f.Addr, CellsFrame, payload)
+}
+
+func (f *Cells) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, CellsFrame, 3)
+	if err != nil {
+		return err
+	}
+
+	if (len(payload)-1)%2 != 0 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as cells frame. %d voltage bytes are not a multiple of 2", data, len(payload)-1))
+	}
+
+	f.Addr = addr
+	f.SourceId = payload[0]
+	f.Voltages = make([]uint16, 0, (len(payload)-1)/2)
+	for i := 1; i < len(payload); i += 2 {
+		f.Voltages = append(f.Voltages, binary.BigEndian.Uint16(payload[i:i+2]))
+	}
+	return nil
+}
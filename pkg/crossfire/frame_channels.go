@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+)
+
+// ChannelsFrameSize address, length, type, 16 channels of 11 bits, crc
+const ChannelsFrameSize = 26
+
+const channelBits = 11
+
+// Channels holds the 16 RC channels, packed as 11 bits each, least significant bit first
+type Channels struct {
+	Addr   Endpoint
+	Values [16]util.CRSFValue
+}
+
+func (f *Channels) Type() FrameType {
+	return ChannelsFrame
+}
+
+func (f *Channels) Marshal() []byte {
+	var payload [22]byte
+	var bits util.CRSFValue
+	var bitsAvailable uint8 = 0
+	var mask util.CRSFValue = (1 << channelBits) - 1
+	offset := 0
+
+	for i := 0; i < 16; i++ {
+		bits |= (f.Values[i] & mask) << bitsAvailable
+		bitsAvailable += channelBits
+		for bitsAvailable >= 8 {
+			payload[offset] = byte(bits)
+			offset += 1
+			bits >>= 8
+			bitsAvailable -= 8
+		}
+	}
+
+	return marshalFrame(f.Addr, ChannelsFrame, payload[:])
+}
+
+func (f *Channels) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, ChannelsFrame, 22)
+	if err != nil {
+		return err
+	}
+
+	if len(data) != ChannelsFrameSize {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as channels frame. expected length %d, but got %d", data, ChannelsFrameSize, len(data)))
+	}
+
+	var bits util.CRSFValue
+	var bitsAvailable uint8 = 0
+	var mask util.CRSFValue = (1 << channelBits) - 1
+	offset := 0
+
+	for i := 0; i < 16; i++ {
+		for bitsAvailable < channelBits {
+			bits |= util.CRSFValue(payload[offset]) << bitsAvailable
+			offset += 1
+			bitsAvailable += 8
+		}
+		f.Values[i] = bits & mask
+		bits >>= channelBits
+		bitsAvailable -= channelBits
+	}
+
+	f.Addr = addr
+	return nil
+}
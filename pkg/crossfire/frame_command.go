@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crc"
+)
+
+// Command is a command frame, unlike other frames it carries an additional crc BA
+// (computed from the frame type up to the end of the payload) before the crc D5
+type Command struct {
+	Addr       Endpoint
+	Dst        Endpoint
+	Src        Endpoint
+	SubCommand FrameType
+	Command    FrameType
+	Payload    []byte
+}
+
+// NewModelSelectCommand tells the module which model id the handset has selected
+func NewModelSelectCommand(modelId uint8) *Command {
+	return &Command{
+		Addr:       Endpoint(UartSyncFrame),
+		Dst:        ModuleEndpoint,
+		Src:        HandsetEndpoint,
+		SubCommand: SubcommandFrame,
+		Command:    CmdModelSelectFrame,
+		Payload:    []byte{modelId},
+	}
+}
+
+// NewSpeedProposalCommand asks the module to switch the CRSF port (portId 0 is the handset port) to a new baud rate
+func NewSpeedProposalCommand(portId uint8, baudRate int32) *Command {
+	return &Command{
+		Addr:       Endpoint(UartSyncFrame),
+		Dst:        ModuleEndpoint,
+		Src:        HandsetEndpoint,
+		SubCommand: GeneralSubcommandFrame,
+		Command:    CmdSpeedProposalFrame,
+		Payload:    binary.BigEndian.AppendUint32([]byte{portId}, uint32(baudRate)),
+	}
+}
+
+// NewSpeedResponseCommand is the module's answer to a speed proposal
+func NewSpeedResponseCommand(portId uint8, accepted bool) *Command {
+	status := uint8(0)
+	if accepted {
+		status = 1
+	}
+
+	return &Command{
+		Addr:       HandsetEndpoint,
+		Dst:        HandsetEndpoint,
+		Src:        ModuleEndpoint,
+		SubCommand: GeneralSubcommandFrame,
+		Command:    CmdSpeedResponseFrame,
+		Payload:    []byte{portId, status},
+	}
+}
+
+func (f *Command) Type() FrameType {
+	return CommandFrame
+}
+
+func (f *Command) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Payload)+3)
+	payload = append(payload, uint8(f.SubCommand), uint8(f.Command))
+	payload = append(payload, f.Payload...)
+
+	inner := append([]byte{uint8(CommandFrame), uint8(f.Dst), uint8(f.Src)}, payload...)
+	return marshalExtFrame(f.Addr, CommandFrame, f.Dst, f.Src, append(payload, crc.BA(inner)))
+}
+
+func (f *Command) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, CommandFrame, 3)
+	if err != nil {
+		return err
+	}
+
+	if crc.BA(data[2:len(data)-2]) != data[len(data)-2] {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as command frame. crc BA mismatch", data))
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.SubCommand = FrameType(payload[0])
+	f.Command = FrameType(payload[1])
+	f.Payload = append([]byte(nil), payload[2:len(payload)-1]...)
+	return nil
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// DeviceInfo is the answer to PingDevices, versions are sent as 4 big-endian bytes (the first one is unused)
+type DeviceInfo struct {
+	Addr             Endpoint
+	Dst              Endpoint
+	Src              Endpoint
+	Name             string
+	SerialNumber     uint32
+	HardwareVersion  uint32
+	SoftwareVersion  uint32
+	FieldCount       uint8
+	ParameterVersion uint8
+}
+
+func (f *DeviceInfo) Type() FrameType {
+	return DeviceInfoFrame
+}
+
+func (f *DeviceInfo) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Name)+15)
+	payload = appendCString(payload, f.Name)
+	payload = binary.BigEndian.AppendUint32(payload, f.SerialNumber)
+	payload = binary.BigEndian.AppendUint32(payload, f.HardwareVersion)
+	payload = binary.BigEndian.AppendUint32(payload, f.SoftwareVersion)
+	payload = append(payload, f.FieldCount, f.ParameterVersion)
+	return marshalExtFrame(f.Addr, DeviceInfoFrame, f.Dst, f.Src, payload)
+}
+
+func (f *DeviceInfo) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, DeviceInfoFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	name, rest, err := cString(payload)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) < 14 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as device info frame. expected 14 bytes after the name, but got %d", data, len(rest)))
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.Name = name
+	f.SerialNumber = binary.BigEndian.Uint32(rest[0:4])
+	f.HardwareVersion = binary.BigEndian.Uint32(rest[4:8])
+	f.SoftwareVersion = binary.BigEndian.Uint32(rest[8:12])
+	f.FieldCount = rest[12]
+	f.ParameterVersion = rest[13]
+	return nil
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// sub commands of the display port frame, the flight controller sends updates and clears,
+// the radio opens, closes and polls the CMS menu
+const (
+	DisplayPortUpdate uint8 = 0x01
+	DisplayPortClear  uint8 = 0x02
+	DisplayPortOpen   uint8 = 0x03
+	DisplayPortClose  uint8 = 0x04
+	DisplayPortPoll   uint8 = 0x05
+)
+
+// DisplayPortMaxRows and DisplayPortMaxCols limit the screen Betaflight draws over CRSF
+const (
+	DisplayPortMaxRows = 16
+	DisplayPortMaxCols = 32
+)
+
+// DisplayPort carries the CMS menu of Betaflight. Updates hold the characters of a single Row,
+// opens hold the size of the screen the radio can show.
+type DisplayPort struct {
+	Addr       Endpoint
+	Dst        Endpoint
+	Src        Endpoint
+	SubCommand uint8
+	Row        uint8
+	Text       []byte
+	Rows       uint8
+	Cols       uint8
+}
+
+func (f *DisplayPort) Type() FrameType {
+	return DisplayPortFrame
+}
+
+func (f *DisplayPort) Marshal() []byte {
+	payload := []byte{f.SubCommand}
+	switch f.SubCommand {
+	case DisplayPortUpdate:
+		payload = append(payload, f.Row)
+		payload = append(payload, f.Text...)
+	case DisplayPortOpen:
+		payload = append(payload, f.Rows, f.Cols)
+	}
+	return marshalExtFrame(f.Addr, DisplayPortFrame, f.Dst, f.Src, payload)
+}
+
+func (f *DisplayPort) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, DisplayPortFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Dst = dst
+	f.Src = src
+	f.SubCommand = payload[0]
+	f.Row = 0
+	f.Text = nil
+	f.Rows = 0
+	f.Cols = 0
+
+	switch f.SubCommand {
+	case DisplayPortUpdate:
+		if len(payload) < 2 {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as display port frame. update has no row", data))
+		}
+		f.Row = payload[1]
+		f.Text = append([]byte(nil), payload[2:]...)
+	case DisplayPortOpen:
+		if len(payload) < 3 {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as display port frame. open has no screen size", data))
+		}
+		f.Rows = payload[1]
+		f.Cols = payload[2]
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+// FlightMode holds the flight mode name sent by the flight controller
+type FlightMode struct {
+	Addr Endpoint
+	Mode string
+}
+
+func (f *FlightMode) Type() FrameType {
+	return FlightModeFrame
+}
+
+func (f *FlightMode) Marshal() []byte {
+	return marshalFrame(f.Addr, FlightModeFrame, appendCString(make([]byte, 0, len(f.Mode)+1), f.Mode))
+}
+
+func (f *FlightMode) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, FlightModeFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	mode, _, err := cString(payload)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Mode = mode
+	return nil
+}
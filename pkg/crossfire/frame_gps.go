@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// GPS holds the wire values of a GPS frame
+type GPS struct {
+	Addr        Endpoint
+	Latitude    int32  // degrees * 10^7
+	Longitude   int32  // degrees * 10^7
+	GroundSpeed uint16 // cm/s
+	Heading     uint16 // degrees * 100
+	Altitude    uint16 // meters + 1000
+	Satellites  uint8
+}
+
+func (f *GPS) Type() FrameType {
+	return GpsFrame
+}
+
+func (f *GPS) Marshal() []byte {
+	payload := make([]byte, 0, 15)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(f.Latitude))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(f.Longitude))
+	payload = binary.BigEndian.AppendUint16(payload, f.GroundSpeed)
+	payload = binary.BigEndian.AppendUint16(payload, f.Heading)
+	payload = binary.BigEndian.AppendUint16(payload, f.Altitude)
+	payload = append(payload, f.Satellites)
+	return marshalFrame(f.Addr, GpsFrame, payload)
+}
+
+func (f *GPS) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, GpsFrame, 15)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Latitude = int32(binary.BigEndian.Uint32(payload[0:4]))
+	f.Longitude = int32(binary.BigEndian.Uint32(payload[4:8]))
+	f.GroundSpeed = binary.BigEndian.Uint16(payload[8:10])
+	f.Heading = binary.BigEndian.Uint16(payload[10:12])
+	f.Altitude = binary.BigEndian.Uint16(payload[12:14])
+	f.Satellites = payload[14]
+	return nil
+}
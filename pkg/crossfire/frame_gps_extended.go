@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// GPSExtended holds the wire values of an extended GPS frame, the velocities and accuracies of the fix
+type GPSExtended struct {
+	Addr                    Endpoint
+	FixType                 uint8
+	NorthSpeed              int16 // cm/s
+	EastSpeed               int16 // cm/s
+	VerticalSpeed           int16 // cm/s, up is positive
+	HorizontalSpeedAccuracy int16 // cm/s
+	TrackAccuracy           int16 // degrees * 10
+	AltitudeEllipsoid       int16 // meters above the GPS ellipsoid (not MSL)
+	HorizontalAccuracy      int16 // cm
+	VerticalAccuracy        int16 // cm
+	Reserved                uint8
+	HDOP                    uint8 // * 10
+	VDOP                    uint8 // * 10
+}
+
+func (f *GPSExtended) Type() FrameType {
+	return GpsExtendedFrame
+}
+
+func (f *GPSExtended) Marshal() []byte {
+	payload := make([]byte, 0, 20)
+	payload = append(payload, f.FixType)
+	for _, value := range []int16{
+		f.NorthSpeed,
+		f.EastSpeed,
+		f.VerticalSpeed,
+		f.HorizontalSpeedAccuracy,
+		f.TrackAccuracy,
+		f.AltitudeEllipsoid,
+		f.HorizontalAccuracy,
+		f.VerticalAccuracy,
+	} {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(value))
+	}
+	payload = append(payload, f.Reserved, f.HDOP, f.VDOP)
+	return marshalFrame(f.Addr, GpsExtendedFrame, payload)
+}
+
+func (f *GPSExtended) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, GpsExtendedFrame, 20)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.FixType = payload[0]
+	f.NorthSpeed = int16(binary.BigEndian.Uint16(payload[1:3]))
+	f.EastSpeed = int16(binary.BigEndian.Uint16(payload[3:5]))
+	f.VerticalSpeed = int16(binary.BigEndian.Uint16(payload[5:7]))
+	f.HorizontalSpeedAccuracy = int16(binary.BigEndian.Uint16(payload[7:9]))
+	f.TrackAccuracy = int16(binary.BigEndian.Uint16(payload[9:11]))
+	f.AltitudeEllipsoid = int16(binary.BigEndian.Uint16(payload[11:13]))
+	f.HorizontalAccuracy = int16(binary.BigEndian.Uint16(payload[13:15]))
+	f.VerticalAccuracy = int16(binary.BigEndian.Uint16(payload[15:17]))
+	f.Reserved = payload[17]
+	f.HDOP = payload[18]
+	f.VDOP = payload[19]
+	return nil
+}
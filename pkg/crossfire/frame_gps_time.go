@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// GPSTime holds the wire values of a GPS time frame, the UTC time of the GPS receiver
+type GPSTime struct {
+	Addr        Endpoint
+	Year        int16
+	Month       uint8
+	Day         uint8
+	Hour        uint8
+	Minute      uint8
+	Second      uint8
+	Millisecond uint16
+}
+
+func (f *GPSTime) Type() FrameType {
+	return GpsTimeFrame
+}
+
+func (f *GPSTime) Marshal() []byte {
+	payload := make([]byte, 0, 9)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(f.Year))
+	payload = append(payload, f.Month, f.Day, f.Hour, f.Minute, f.Second)
+	payload = binary.BigEndian.AppendUint16(payload, f.Millisecond)
+	return marshalFrame(f.Addr, GpsTimeFrame, payload)
+}
+
+func (f *GPSTime) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, GpsTimeFrame, 9)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Year = int16(binary.BigEndian.Uint16(payload[0:2]))
+	f.Month = payload[2]
+	f.Day = payload[3]
+	f.Hour = payload[4]
+	f.Minute = payload[5]
+	f.Second = payload[6]
+	f.Millisecond = binary.BigEndian.Uint16(payload[7:9])
+	return nil
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// Heartbeat is sent periodically by devices to tell that they are alive
+type Heartbeat struct {
+	Addr   Endpoint
+	Origin Endpoint
+}
+
+func (f *Heartbeat) Type() FrameType {
+	return HeartbeatFrame
+}
+
+func (f *Heartbeat) Marshal() []byte {
+	//the origin address is sent as a 16 bit value
+	return marshalFrame(f.Addr, HeartbeatFrame, binary.BigEndian.AppendUint16(nil, uint16(f.Origin)))
+}
+
+func (f *Heartbeat) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, HeartbeatFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.Origin = Endpoint(binary.BigEndian.Uint16(payload[0:2]))
+	return nil
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+// LinkStats holds the wire values of a link statistics frame
+type LinkStats struct {
+	Addr          Endpoint
+	UplinkRSSI1   int8 // dBm
+	UplinkRSSI2   int8 // dBm
+	UplinkLQ      uint8
+	UplinkSNR     int8 // dB
+	ActiveAntenna uint8
+	RFMode        uint8
+	UplinkPower   uint8
+	DownlinkRSSI  int8 // dBm
+	DownlinkLQ    uint8
+	DownlinkSNR   int8 // dB
+}
+
+func (f *LinkStats) Type() FrameType {
+	return LinkStatsFrame
+}
+
+func (f *LinkStats) Marshal() []byte {
+	return marshalFrame(f.Addr, LinkStatsFrame, []byte{
+		/* 0: */ uint8(f.UplinkRSSI1),
+		/* 1: */ uint8(f.UplinkRSSI2),
+		/* 2: */ f.UplinkLQ,
+		/* 3: */ uint8(f.UplinkSNR),
+		/* 4: */ f.ActiveAntenna,
+		/* 5: */ f.RFMode,
+		/* 6: */ f.UplinkPower,
+		/* 7: */ uint8(f.DownlinkRSSI),
+		/* 8: */ f.DownlinkLQ,
+		/* 9: */ uint8(f.DownlinkSNR),
+	})
+}
+
+func (f *LinkStats) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, LinkStatsFrame, 10)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.UplinkRSSI1 = int8(payload[0])
+	f.UplinkRSSI2 = int8(payload[1])
+	f.UplinkLQ = payload[2]
+	f.UplinkSNR = int8(payload[3])
+	f.ActiveAntenna = payload[4]
+	f.RFMode = payload[5]
+	f.UplinkPower = payload[6]
+	f.DownlinkRSSI = int8(payload[7])
+	f.DownlinkLQ = payload[8]
+	f.DownlinkSNR = int8(payload[9])
+	return nil
+}
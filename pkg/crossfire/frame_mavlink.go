@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxMavlinkChunkSize the MAVLink bytes that fit into an envelope
+// (64 - address, length, type, chunk counts, data size, crc)
+const MaxMavlinkChunkSize = MaxFrameSize - 6
+
+// MaxMavlinkChunks an envelope is split into at most 15 chunks (the chunk counts are 4 bits)
+const MaxMavlinkChunks = 15
+
+// MavlinkEnvelope carries a chunk of MAVLink bytes, when ELRS runs the link in MAVLink mode
+type MavlinkEnvelope struct {
+	Addr         Endpoint
+	TotalChunks  uint8
+	CurrentChunk uint8
+	Data         []byte
+}
+
+func (f *MavlinkEnvelope) Type() FrameType {
+	return MavlinkEnvelopeFrame
+}
+
+func (f *MavlinkEnvelope) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Data)+2)
+	//total chunks in the low nibble, current chunk in the high nibble
+	payload = append(payload, f.TotalChunks&0x0F|f.CurrentChunk<<4, uint8(len(f.Data)))
+	payload = append(payload, f.Data...)
+	return marshalFrame(f.Addr, MavlinkEnvelopeFrame, payload)
+}
+
+func (f *MavlinkEnvelope) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, MavlinkEnvelopeFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	size := int(payload[1])
+	if size > len(payload)-2 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as mavlink envelope frame. data size is %d, but only %d bytes follow", data, size, len(payload)-2))
+	}
+
+	f.Addr = addr
+	f.TotalChunks = payload[0] & 0x0F
+	f.CurrentChunk = payload[0] >> 4
+	f.Data = append([]byte(nil), payload[2:2+size]...)
+	return nil
+}
+
+// NewMavlinkEnvelopes splits MAVLink bytes into envelopes, data too long for one envelope is
+// spread over several (each with its own chunk count)
+func NewMavlinkEnvelopes(addr Endpoint, data []byte) []*MavlinkEnvelope {
+	var envelopes []*MavlinkEnvelope
+	for len(data) > 0 {
+		count := min(len(data), MaxMavlinkChunks*MaxMavlinkChunkSize)
+		total := uint8((count + MaxMavlinkChunkSize - 1) / MaxMavlinkChunkSize)
+
+		for chunk := uint8(0); chunk < total; chunk++ {
+			size := min(len(data), MaxMavlinkChunkSize)
+			envelopes = append(envelopes, &MavlinkEnvelope{
+				Addr:         addr,
+				TotalChunks:  total,
+				CurrentChunk: chunk,
+				Data:         data[:size],
+			})
+			data = data[size:]
+		}
+	}
+	return envelopes
+}
+
+// MavlinkAssembler joins the chunks of envelopes, which have to arrive in order
+type MavlinkAssembler struct {
+	nextChunk uint8
+	data      []byte
+}
+
+// Push adds an envelope, it returns the MAVLink bytes once the last chunk of an envelope has been pushed.
+// Chunks that arrive out of order drop the envelope.
+func (a *MavlinkAssembler) Push(envelope *MavlinkEnvelope) ([]byte, error) {
+	if envelope.CurrentChunk == 0 {
+		a.nextChunk = 0
+		a.data = a.data[:0]
+	}
+
+	if envelope.CurrentChunk != a.nextChunk || envelope.CurrentChunk >= envelope.TotalChunks {
+		a.nextChunk = 0
+		a.data = a.data[:0]
+		return nil, errors.New(fmt.Sprintf("mavlink envelope chunk %d of %d is out of order", envelope.CurrentChunk, envelope.TotalChunks))
+	}
+
+	a.data = append(a.data, envelope.Data...)
+	a.nextChunk++
+	if a.nextChunk < envelope.TotalChunks {
+		return nil, nil
+	}
+
+	data := append([]byte(nil), a.data...)
+	a.nextChunk = 0
+	a.data = a.data[:0]
+	return data, nil
+}
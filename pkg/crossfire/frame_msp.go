@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MSP status byte: sequence number, start of packet, MSP version and error flag
+const (
+	MSPSequenceMask uint8 = 0x0F
+	MSPStartFlag    uint8 = 0x10
+	MSPVersionMask  uint8 = 0x60
+	MSPVersionShift       = 5
+	MSPErrorFlag    uint8 = 0x80
+)
+
+// MSP carries one chunk of an MSP packet tunnelled through CRSF.
+// FrameType is MspRequestFrame, MspResponseFrame or MspWriteFrame.
+type MSP struct {
+	Addr      Endpoint
+	FrameType FrameType
+	Dst       Endpoint
+	Src       Endpoint
+	Status    uint8
+	Chunk     []byte
+}
+
+func (f *MSP) Type() FrameType {
+	return f.FrameType
+}
+
+func (f *MSP) Sequence() uint8 {
+	return f.Status & MSPSequenceMask
+}
+
+func (f *MSP) IsStart() bool {
+	return f.Status&MSPStartFlag != 0
+}
+
+func (f *MSP) Version() MSPVersion {
+	return MSPVersion((f.Status & MSPVersionMask) >> MSPVersionShift)
+}
+
+func (f *MSP) IsError() bool {
+	return f.Status&MSPErrorFlag != 0
+}
+
+func (f *MSP) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Chunk)+1)
+	payload = append(payload, f.Status)
+	payload = append(payload, f.Chunk...)
+	return marshalExtFrame(f.Addr, f.FrameType, f.Dst, f.Src, payload)
+}
+
+func (f *MSP) Unmarshal(data []byte) error {
+	if len(data) < 3 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as msp frame. length is too small", data))
+	}
+
+	fType := FrameType(data[2])
+	if fType != MspRequestFrame && fType != MspResponseFrame && fType != MspWriteFrame {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as msp frame. frame type is %x", data, data[2]))
+	}
+
+	addr, dst, src, payload, err := unmarshalExtFrame(data, fType, 1)
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.FrameType, f.Dst, f.Src = addr, fType, dst, src
+	f.Status = payload[0]
+	f.Chunk = append([]byte(nil), payload[1:]...)
+	return nil
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+// ParameterEntry carries one chunk of a parameter's description, chunks are sent
+// in order until ChunksRemaining reaches 0
+type ParameterEntry struct {
+	Addr            Endpoint
+	Dst             Endpoint
+	Src             Endpoint
+	FieldId         uint8
+	ChunksRemaining uint8
+	Chunk           []byte
+}
+
+func (f *ParameterEntry) Type() FrameType {
+	return ParameterSettingsEntryFrame
+}
+
+func (f *ParameterEntry) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Chunk)+2)
+	payload = append(payload, f.FieldId, f.ChunksRemaining)
+	payload = append(payload, f.Chunk...)
+	return marshalExtFrame(f.Addr, ParameterSettingsEntryFrame, f.Dst, f.Src, payload)
+}
+
+func (f *ParameterEntry) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, ParameterSettingsEntryFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.FieldId = payload[0]
+	f.ChunksRemaining = payload[1]
+	f.Chunk = append([]byte(nil), payload[2:]...)
+	return nil
+}
+
+// ParameterRead asks a device for one chunk of a parameter's description
+type ParameterRead struct {
+	Addr       Endpoint
+	Dst        Endpoint
+	Src        Endpoint
+	FieldId    uint8
+	ChunkIndex uint8
+}
+
+func (f *ParameterRead) Type() FrameType {
+	return ParameterSettingsReadFrame
+}
+
+func (f *ParameterRead) Marshal() []byte {
+	return marshalExtFrame(f.Addr, ParameterSettingsReadFrame, f.Dst, f.Src, []byte{f.FieldId, f.ChunkIndex})
+}
+
+func (f *ParameterRead) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, ParameterSettingsReadFrame, 2)
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.FieldId = payload[0]
+	f.ChunkIndex = payload[1]
+	return nil
+}
+
+// ParameterWrite sets a parameter's value, the encoding of Value depends on the parameter's type
+type ParameterWrite struct {
+	Addr    Endpoint
+	Dst     Endpoint
+	Src     Endpoint
+	FieldId uint8
+	Value   []byte
+}
+
+func (f *ParameterWrite) Type() FrameType {
+	return ParameterSettingsWriteFrame
+}
+
+func (f *ParameterWrite) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Value)+1)
+	payload = append(payload, f.FieldId)
+	payload = append(payload, f.Value...)
+	return marshalExtFrame(f.Addr, ParameterSettingsWriteFrame, f.Dst, f.Src, payload)
+}
+
+func (f *ParameterWrite) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, ParameterSettingsWriteFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.FieldId = payload[0]
+	f.Value = append([]byte(nil), payload[1:]...)
+	return nil
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sub types of the ArduPilot passthrough frame
+const (
+	PassthroughSinglePacket uint8 = 0xF0
+	PassthroughStatusText   uint8 = 0xF1
+	PassthroughMultiPacket  uint8 = 0xF2
+)
+
+// MaxPassthroughPackets the packets that fit into a multi packet frame
+const MaxPassthroughPackets = 9
+
+// MaxPassthroughTextSize the text of status text frames is sent as a fixed size, zero padded field
+const MaxPassthroughTextSize = 50
+
+// PassthroughPacket is a FrSky passthrough sensor value, AppId tells how Data is packed
+type PassthroughPacket struct {
+	AppId uint16
+	Data  uint32
+}
+
+// Passthrough carries the FrSky passthrough telemetry of ArduPilot. Single and multi packet frames
+// hold Packets, status text frames hold Severity and Text. ArduPilot sends the values little endian.
+type Passthrough struct {
+	Addr     Endpoint
+	SubType  uint8
+	Packets  []PassthroughPacket
+	Severity uint8
+	Text     string
+}
+
+func (f *Passthrough) Type() FrameType {
+	return ArduPilotPassthroughFrame
+}
+
+func (f *Passthrough) Marshal() []byte {
+	var payload []byte
+
+	switch f.SubType {
+	case PassthroughStatusText:
+		payload = make([]byte, 2+MaxPassthroughTextSize)
+		payload[0] = f.SubType
+		payload[1] = f.Severity
+		//keep the last byte zero, the text is nul terminated
+		copy(payload[2:len(payload)-1], f.Text)
+	case PassthroughSinglePacket:
+		payload = []byte{f.SubType}
+		if len(f.Packets) > 0 {
+			payload = binary.LittleEndian.AppendUint16(payload, f.Packets[0].AppId)
+			payload = binary.LittleEndian.AppendUint32(payload, f.Packets[0].Data)
+		}
+	default:
+		packets := f.Packets[:min(len(f.Packets), MaxPassthroughPackets)]
+		payload = append(make([]byte, 0, 2+6*len(packets)), f.SubType, uint8(len(packets)))
+		for _, packet := range packets {
+			payload = binary.LittleEndian.AppendUint16(payload, packet.AppId)
+			payload = binary.LittleEndian.AppendUint32(payload, packet.Data)
+		}
+	}
+
+	return marshalFrame(f.Addr, ArduPilotPassthroughFrame, payload)
+}
+
+func (f *Passthrough) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, ArduPilotPassthroughFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	f.Addr = addr
+	f.SubType = payload[0]
+	f.Packets = nil
+	f.Severity = 0
+	f.Text = ""
+
+	switch f.SubType {
+	case PassthroughSinglePacket:
+		if len(payload) < 7 {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. single packet needs 7 payload bytes, but got %d", data, len(payload)))
+		}
+		f.Packets = []PassthroughPacket{readPassthroughPacket(payload[1:7])}
+	case PassthroughMultiPacket:
+		if len(payload) < 2 {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. packet count is missing", data))
+		}
+		count := int(payload[1])
+		if count > MaxPassthroughPackets || len(payload) < 2+6*count {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. %d packets do not fit into %d payload bytes", data, count, len(payload)))
+		}
+		f.Packets = make([]PassthroughPacket, count)
+		for i := range f.Packets {
+			f.Packets[i] = readPassthroughPacket(payload[2+6*i : 8+6*i])
+		}
+	case PassthroughStatusText:
+		if len(payload) < 2 {
+			return errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. severity is missing", data))
+		}
+		f.Severity = payload[1]
+		text := string(payload[2:])
+		if end := strings.IndexByte(text, 0); end >= 0 {
+			text = text[:end]
+		}
+		f.Text = text
+	default:
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. unknown sub type %x", data, f.SubType))
+	}
+
+	return nil
+}
+
+func readPassthroughPacket(data []byte) PassthroughPacket {
+	return PassthroughPacket{
+		AppId: binary.LittleEndian.Uint16(data[0:2]),
+		Data:  binary.LittleEndian.Uint32(data[2:6]),
+	}
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+// PingDevices asks every device on the bus to answer with a DeviceInfo frame
+type PingDevices struct {
+	Addr Endpoint
+	Dst  Endpoint
+	Src  Endpoint
+}
+
+func (f *PingDevices) Type() FrameType {
+	return PingDevicesFrame
+}
+
+func (f *PingDevices) Marshal() []byte {
+	return marshalExtFrame(f.Addr, PingDevicesFrame, f.Dst, f.Src, nil)
+}
+
+func (f *PingDevices) Unmarshal(data []byte) error {
+	addr, dst, src, _, err := unmarshalExtFrame(data, PingDevicesFrame, 0)
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	return nil
+}
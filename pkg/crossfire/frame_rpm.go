@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxRPMValues the 24 bit values that fit into an RPM frame
+const MaxRPMValues = 19
+
+// RPM holds the wire values of an RPM frame, e.g. the motor speeds reported by the ESCs
+type RPM struct {
+	Addr     Endpoint
+	SourceId uint8
+	Values   []int32 // revolutions per minute, 24 bit signed
+}
+
+func (f *RPM) Type() FrameType {
+	return RpmFrame
+}
+
+func (f *RPM) Marshal() []byte {
+	values := f.Values[:min(len(f.Values), MaxRPMValues)]
+	payload := make([]byte, 0, 1+3*len(values))
+	payload = append(payload, f.SourceId)
+	for _, value := range values {
+		payload = append(payload, uint8(value>>16), uint8(value>>8), uint8(value))
+	}
+	return marshalFrame(f.Addr, RpmFrame, payload)
+}
+
+func (f *RPM) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, RpmFrame, 4)
+	if err != nil {
+		return err
+	}
+
+	if (len(payload)-1)%3 != 0 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as rpm frame. %d value bytes are not a multiple of 3", data, len(payload)-1))
+	}
+
+	f.Addr = addr
+	f.SourceId = payload[0]
+	f.Values = make([]int32, 0, (len(payload)-1)/3)
+	for i := 1; i < len(payload); i += 3 {
+		//shift into the top of an int32 and back to extend the sign
+		value := int32(uint32(payload[i])<<24|uint32(payload[i+1])<<16|uint32(payload[i+2])<<8) >> 8
+		f.Values = append(f.Values, value)
+	}
+	return nil
+}
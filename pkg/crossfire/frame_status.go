@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "encoding/binary"
+
+// Status is the ELRS link status (elrs-status) frame
+type Status struct {
+	Addr        Endpoint
+	Dst         Endpoint
+	Src         Endpoint
+	BadPackets  uint8
+	GoodPackets uint16
+	Flags       uint8
+	Message     string
+}
+
+func (f *Status) Type() FrameType {
+	return StatusFrame
+}
+
+func (f *Status) Marshal() []byte {
+	payload := make([]byte, 0, len(f.Message)+5)
+	payload = append(payload, f.BadPackets)
+	payload = binary.BigEndian.AppendUint16(payload, f.GoodPackets)
+	payload = append(payload, f.Flags)
+	payload = appendCString(payload, f.Message)
+	return marshalExtFrame(f.Addr, StatusFrame, f.Dst, f.Src, payload)
+}
+
+func (f *Status) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, StatusFrame, 5)
+	if err != nil {
+		return err
+	}
+
+	message, _, err := cString(payload[4:])
+	if err != nil {
+		return err
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.BadPackets = payload[0]
+	f.GoodPackets = binary.BigEndian.Uint16(payload[1:3])
+	f.Flags = payload[3]
+	f.Message = message
+	return nil
+}
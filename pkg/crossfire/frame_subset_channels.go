@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+)
+
+// ChannelResolution is the number of bits used for each channel value
+type ChannelResolution uint8
+
+//goland:noinspection GoUnusedConst
+const (
+	Resolution10Bit ChannelResolution = 10
+	Resolution11Bit ChannelResolution = 11
+	Resolution12Bit ChannelResolution = 12
+	Resolution13Bit ChannelResolution = 13
+)
+
+// MaxChannels the subset channels frame addresses channels with 5 bits
+const MaxChannels = 32
+
+// MaxSubsetChannelsPayload address, length, type, configuration and crc do not carry channel values
+const MaxSubsetChannelsPayload = MaxFrameSize - 5
+
+func (r ChannelResolution) IsValid() bool {
+	return r >= Resolution10Bit && r <= Resolution13Bit
+}
+
+// MaxValue the largest channel value at this resolution
+func (r ChannelResolution) MaxValue() util.CRSFValue {
+	return (1 << r) - 1
+}
+
+// MaxSubsetChannels how many channels at this resolution fit into a single subset channels frame
+func (r ChannelResolution) MaxSubsetChannels() int {
+	return min(MaxSubsetChannelsPayload*8/int(r), MaxChannels)
+}
+
+// ScaleChannel converts a channel value from one resolution to another, keeping its position within the range
+func ScaleChannel(value util.CRSFValue, from ChannelResolution, to ChannelResolution) util.CRSFValue {
+	if to >= from {
+		return value << (to - from)
+	}
+	return value >> (from - to)
+}
+
+// SubsetChannels carries a run of channels, starting at StartChannel, with Values at the given Resolution.
+// The first payload byte holds the starting channel (bits 0-4) and the resolution (bits 5-6, 0 is 10 bits),
+// followed by the values packed least significant bit first, like the classic channels frame.
+type SubsetChannels struct {
+	Addr         Endpoint
+	StartChannel uint8
+	Resolution   ChannelResolution
+	Values       []util.CRSFValue
+}
+
+func (f *SubsetChannels) Type() FrameType {
+	return SubsetChannelsFrame
+}
+
+func (f *SubsetChannels) Marshal() []byte {
+	bitCount := len(f.Values) * int(f.Resolution)
+	payload := make([]byte, 1, 1+(bitCount+7)/8)
+	payload[0] = (f.StartChannel & 0x1F) | uint8(f.Resolution-Resolution10Bit)<<5
+
+	var bits util.CRSFValue
+	var bitsAvailable uint8 = 0
+	mask := f.Resolution.MaxValue()
+
+	for _, value := range f.Values {
+		bits |= (value & mask) << bitsAvailable
+		bitsAvailable += uint8(f.Resolution)
+		for bitsAvailable >= 8 {
+			payload = append(payload, byte(bits))
+			bits >>= 8
+			bitsAvailable -= 8
+		}
+	}
+
+	if bitsAvailable > 0 {
+		payload = append(payload, byte(bits))
+	}
+
+	return marshalFrame(f.Addr, SubsetChannelsFrame, payload)
+}
+
+// Unmarshal decodes as many whole channel values as the payload holds
+func (f *SubsetChannels) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, SubsetChannelsFrame, 1)
+	if err != nil {
+		return err
+	}
+
+	resolution := Resolution10Bit + ChannelResolution((payload[0]>>5)&0x03)
+	count := (len(payload) - 1) * 8 / int(resolution)
+	if int(payload[0]&0x1F)+count > MaxChannels {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as subset channels frame. channels %d to %d are out of range", data, payload[0]&0x1F, int(payload[0]&0x1F)+count-1))
+	}
+
+	var bits util.CRSFValue
+	var bitsAvailable uint8 = 0
+	mask := resolution.MaxValue()
+	offset := 1
+
+	values := make([]util.CRSFValue, count)
+	for i := 0; i < count; i++ {
+		for bitsAvailable < uint8(resolution) {
+			bits |= util.CRSFValue(payload[offset]) << bitsAvailable
+			offset += 1
+			bitsAvailable += 8
+		}
+		values[i] = bits & mask
+		bits >>= resolution
+		bitsAvailable -= uint8(resolution)
+	}
+
+	f.Addr = addr
+	f.StartChannel = payload[0] & 0x1F
+	f.Resolution = resolution
+	f.Values = values
+	return nil
+}
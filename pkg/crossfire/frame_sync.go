@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// OpenTxSync is the radio frame the module uses to tell the handset when to send channels,
+// Rate and Offset are in units of 0.1 microseconds
+type OpenTxSync struct {
+	Addr   Endpoint
+	Dst    Endpoint
+	Src    Endpoint
+	Rate   int32
+	Offset int32
+}
+
+func (f *OpenTxSync) Type() FrameType {
+	return RadioFrame
+}
+
+func (f *OpenTxSync) Marshal() []byte {
+	payload := make([]byte, 0, 9)
+	payload = append(payload, uint8(OpenTxSyncFrame))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(f.Rate))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(f.Offset))
+	return marshalExtFrame(f.Addr, RadioFrame, f.Dst, f.Src, payload)
+}
+
+func (f *OpenTxSync) Unmarshal(data []byte) error {
+	addr, dst, src, payload, err := unmarshalExtFrame(data, RadioFrame, 9)
+	if err != nil {
+		return err
+	}
+
+	if FrameType(payload[0]) != OpenTxSyncFrame {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as sync frame. radio sub-type is %x", data, payload[0]))
+	}
+
+	f.Addr, f.Dst, f.Src = addr, dst, src
+	f.Rate = int32(binary.BigEndian.Uint32(payload[1:5]))
+	f.Offset = int32(binary.BigEndian.Uint32(payload[5:9]))
+	return nil
+}
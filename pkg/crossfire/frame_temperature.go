@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MaxTemperatureValues the temperatures that fit into a temperature frame
+const MaxTemperatureValues = 20
+
+// Temperature holds the wire values of a temperature frame
+type Temperature struct {
+	Addr     Endpoint
+	SourceId uint8
+	Values   []int16 // degrees celsius * 10
+}
+
+func (f *Temperature) Type() FrameType {
+	return TemperatureFrame
+}
+
+func (f *Temperature) Marshal() []byte {
+	values := f.Values[:min(len(f.Values), MaxTemperatureValues)]
+	payload := make([]byte, 0, 1+2*len(values))
+	payload = append(payload, f.SourceId)
+	for _, value := range values {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(value))
+	}
+	return marshalFrame(f.Addr, TemperatureFrame, payload)
+}
+
+func (f *Temperature) Unmarshal(data []byte) error {
+	addr, payload, err := unmarshalFrame(data, TemperatureFrame, 3)
+	if err != nil {
+		return err
+	}
+
+	if (len(payload)-1)%2 != 0 {
+		return errors.New(fmt.Sprintf("cannot unmarshal %x as temperature frame. %d value bytes are not a multiple of 2", data, len(payload)-1))
+	}
+
+	f.Addr = addr
+	f.SourceId = payload[0]
+	f.Values = make([]int16, 0, (len(payload)-1)/2)
+	for i := 1; i < len(payload); i += 2 {
+		f.Values = append(f.Values, int16(binary.BigEndian.Uint16(payload[i:i+2])))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MSPRequestChunkSize how much of an MSP request is sent per frame. The CRSF frame could carry more,
+// but older flight controller and ELRS firmware only accept 8 bytes (like the Betaflight Lua scripts send).
+const MSPRequestChunkSize = 8
+
+// MSPResponseChunkSize how much of an MSP response fits into a frame
+// (64 - address, length, type, dst, src, status, crc)
+const MSPResponseChunkSize = MaxFrameSize - 7
+
+type MSPVersion uint8
+
+const (
+	MSPv1 MSPVersion = 1
+	MSPv2 MSPVersion = 2
+)
+
+const (
+	mspV1HeaderSize = 2 //size, command
+	mspV2HeaderSize = 5 //flags, command (2), size (2)
+)
+
+// MSPPacket is a complete MSP request or response, as carried by one or more MSP frames
+type MSPPacket struct {
+	Version MSPVersion
+	Command uint16
+	Payload []byte
+
+	//Error is set on responses to requests the flight controller could not handle
+	Error bool
+}
+
+// MSPVersionFor returns the lowest MSP version that can carry the command and payload
+func MSPVersionFor(command uint16, payloadSize int) MSPVersion {
+	if command < 0xFF && payloadSize < 0xFF {
+		return MSPv1
+	}
+	return MSPv2
+}
+
+// encode returns the packet as tunnelled through CRSF, MSPv1 ends with a checksum (MSPv2 relies on the CRSF crc)
+func (p *MSPPacket) encode() ([]byte, error) {
+	switch p.Version {
+	case MSPv1:
+		if p.Command >= 0xFF || len(p.Payload) >= 0xFF {
+			return nil, errors.New(fmt.Sprintf("msp command %d with %d payload bytes does not fit into MSPv1", p.Command, len(p.Payload)))
+		}
+		data := make([]byte, 0, mspV1HeaderSize+len(p.Payload)+1)
+		data = append(data, uint8(len(p.Payload)), uint8(p.Command))
+		data = append(data, p.Payload...)
+		return append(data, mspChecksum(data)), nil
+	case MSPv2:
+		if len(p.Payload) > 0xFFFF {
+			return nil, errors.New(fmt.Sprintf("msp payload of %d bytes is too large", len(p.Payload)))
+		}
+		data := make([]byte, 0, mspV2HeaderSize+len(p.Payload))
+		data = append(data, 0)
+		data = binary.LittleEndian.AppendUint16(data, p.Command)
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(p.Payload)))
+		return append(data, p.Payload...), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown msp version %d", p.Version))
+	}
+}
+
+// Fragment splits the packet into frames of at most chunkSize bytes (after the status byte).
+// seq is the sequence number of the first frame, the sequence number following the last frame is returned.
+func (p *MSPPacket) Fragment(fType FrameType, addr Endpoint, dst Endpoint, src Endpoint, seq uint8, chunkSize int) ([]*MSP, uint8, error) {
+	data, err := p.encode()
+	if err != nil {
+		return nil, seq, err
+	}
+
+	var frames []*MSP
+	for start := true; start || len(data) > 0; start = false {
+		chunk := data[:min(chunkSize, len(data))]
+		data = data[len(chunk):]
+
+		status := seq&MSPSequenceMask | uint8(p.Version)<<MSPVersionShift&MSPVersionMask
+		if start {
+			status |= MSPStartFlag
+		}
+		if p.Error {
+			status |= MSPErrorFlag
+		}
+
+		frames = append(frames, &MSP{Addr: addr, FrameType: fType, Dst: dst, Src: src, Status: status, Chunk: chunk})
+		seq = (seq + 1) & MSPSequenceMask
+	}
+
+	return frames, seq, nil
+}
+
+// MSPAssembler puts MSP packets back together from their frames, which have to arrive in sequence
+type MSPAssembler struct {
+	started bool
+	lastSeq uint8
+	version MSPVersion
+	isError bool
+	data    []byte
+}
+
+// Push adds a frame. It returns the packet once its last frame has been pushed, and nil until then.
+// A frame out of sequence drops the partial packet.
+func (a *MSPAssembler) Push(frame *MSP) (*MSPPacket, error) {
+	if frame.IsStart() {
+		a.started = true
+		a.version = frame.Version()
+		a.isError = frame.IsError()
+		a.data = a.data[:0]
+	} else if !a.started {
+		return nil, errors.New(fmt.Sprintf("msp frame %d is not part of a packet", frame.Sequence()))
+	} else if frame.Sequence() != (a.lastSeq+1)&MSPSequenceMask {
+		a.started = false
+		return nil, errors.New(fmt.Sprintf("msp frame %d is out of sequence, expected %d", frame.Sequence(), (a.lastSeq+1)&MSPSequenceMask))
+	}
+
+	a.lastSeq = frame.Sequence()
+	a.data = append(a.data, frame.Chunk...)
+
+	packet, err := a.decode()
+	if packet != nil || err != nil {
+		a.started = false
+	}
+	return packet, err
+}
+
+// decode returns the packet if all of its bytes have been received
+func (a *MSPAssembler) decode() (*MSPPacket, error) {
+	switch a.version {
+	case MSPv1:
+		if len(a.data) < mspV1HeaderSize {
+			return nil, nil
+		}
+		size := int(a.data[0])
+		if len(a.data) < mspV1HeaderSize+size+1 {
+			return nil, nil
+		}
+		body := a.data[:mspV1HeaderSize+size]
+		if checksum := a.data[mspV1HeaderSize+size]; checksum != mspChecksum(body) {
+			return nil, errors.New(fmt.Sprintf("msp command %d checksum mismatch, got %x, computed %x", body[1], checksum, mspChecksum(body)))
+		}
+		return &MSPPacket{
+			Version: MSPv1,
+			Command: uint16(body[1]),
+			Payload: append([]byte(nil), body[mspV1HeaderSize:]...),
+			Error:   a.isError,
+		}, nil
+	case MSPv2:
+		if len(a.data) < mspV2HeaderSize {
+			return nil, nil
+		}
+		size := int(binary.LittleEndian.Uint16(a.data[3:5]))
+		if len(a.data) < mspV2HeaderSize+size {
+			return nil, nil
+		}
+		return &MSPPacket{
+			Version: MSPv2,
+			Command: binary.LittleEndian.Uint16(a.data[1:3]),
+			Payload: append([]byte(nil), a.data[mspV2HeaderSize:mspV2HeaderSize+size]...),
+			Error:   a.isError,
+		}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown msp version %d", a.version))
+	}
+}
+
+func mspChecksum(data []byte) uint8 {
+	var checksum uint8
+	for _, b := range data {
+		checksum ^= b
+	}
+	return checksum
+}
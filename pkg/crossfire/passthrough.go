@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import "fmt"
+
+// FrSky passthrough sensor ids sent by ArduPilot
+const (
+	PassthroughTextId          uint16 = 0x5000
+	PassthroughAPStatusId      uint16 = 0x5001
+	PassthroughGPSStatusId     uint16 = 0x5002
+	PassthroughBattery1Id      uint16 = 0x5003
+	PassthroughHomeId          uint16 = 0x5004
+	PassthroughVelocityYawId   uint16 = 0x5005
+	PassthroughAttitudeRangeId uint16 = 0x5006
+	PassthroughParameterId     uint16 = 0x5007
+	PassthroughBattery2Id      uint16 = 0x5008
+)
+
+// ids of the parameters ArduPilot sends in passthrough parameter packets
+const (
+	APParamFrameType        uint8 = 1
+	APParamBattery1Capacity uint8 = 4
+	APParamBattery2Capacity uint8 = 5
+)
+
+// APStatusText is a status message of ArduPilot, Severity is the MAVLink severity (0 emergency ... 7 debug)
+type APStatusText struct {
+	Severity uint8
+	Text     string
+}
+
+var apSeverityNames = []string{"emergency", "alert", "critical", "error", "warning", "notice", "info", "debug"}
+
+func (t APStatusText) SeverityName() string {
+	if int(t.Severity) < len(apSeverityNames) {
+		return apSeverityNames[t.Severity]
+	}
+	return fmt.Sprintf("%d", t.Severity)
+}
+
+type APStatus struct {
+	FlightMode      uint8 // ArduPilot mode number of the vehicle type
+	SimpleMode      bool
+	SuperSimpleMode bool
+	Flying          bool
+	Armed           bool
+	BatteryFailsafe bool
+	EKFFailsafe     bool
+	Failsafe        bool
+	FencePresent    bool
+	FenceBreached   bool
+	Throttle        float32 // in percent
+	IMUTemperature  int32   // in degrees celsius
+}
+
+type APGPSStatus struct {
+	Satellites  uint8
+	FixType     uint8 // 0 no gps, 1 no fix, 2 2D, 3 3D, 4 DGPS, 5 RTK float, 6 RTK fixed
+	HDOP        float32
+	AltitudeMSL float32 // in meters
+}
+
+type APBattery struct {
+	Index    uint8   // 1 or 2
+	Voltage  float32 // in volts
+	Current  float32 // in amps
+	Consumed uint32  // in mAh
+}
+
+type APHome struct {
+	Distance uint32  // in meters
+	Altitude float32 // in meters above home
+	Bearing  uint16  // in degrees, from the vehicle to home
+}
+
+type APVelocityYaw struct {
+	VerticalSpeed   float32 // in meters/second, up is positive
+	HorizontalSpeed float32 // in meters/second
+	Yaw             float32 // in degrees
+	Airspeed        bool    // HorizontalSpeed is the airspeed, not the ground speed
+}
+
+type APAttitudeRange struct {
+	Roll        float32 // in degrees
+	Pitch       float32 // in degrees
+	RangeFinder float32 // in meters
+}
+
+type APParameter struct {
+	Id    uint8
+	Value uint32
+}
+
+// PassthroughDecoder turns passthrough frames into typed values (the AP* types). Packets of unknown sensor ids
+// are passed on as PassthroughPacket. It keeps the status texts that are spread over several packets.
+type PassthroughDecoder struct {
+	text StatusTextAssembler
+}
+
+func (d *PassthroughDecoder) Decode(frame *Passthrough) []any {
+	if frame.SubType == PassthroughStatusText {
+		return []any{APStatusText{Severity: frame.Severity, Text: frame.Text}}
+	}
+
+	values := make([]any, 0, len(frame.Packets))
+	for _, packet := range frame.Packets {
+		if packet.AppId == PassthroughTextId {
+			if text := d.text.Push(packet.Data); text != nil {
+				values = append(values, *text)
+			}
+			continue
+		}
+		values = append(values, DecodePassthroughPacket(packet))
+	}
+	return values
+}
+
+// DecodePassthroughPacket decodes a single packet, text packets and unknown sensor ids are returned unchanged
+func DecodePassthroughPacket(packet PassthroughPacket) any {
+	data := packet.Data
+
+	switch packet.AppId {
+	case PassthroughAPStatusId:
+		var mode uint8
+		if data&0x1F > 0 {
+			//ArduPilot sends the mode number + 1
+			mode = uint8(data&0x1F) - 1
+		}
+		return APStatus{
+			FlightMode:      mode,
+			SimpleMode:      data&(1<<5) != 0,
+			SuperSimpleMode: data&(1<<6) != 0,
+			Flying:          data&(1<<7) != 0,
+			Armed:           data&(1<<8) != 0,
+			BatteryFailsafe: data&(1<<9) != 0,
+			EKFFailsafe:     data&(3<<10) != 0,
+			Failsafe:        data&(1<<12) != 0,
+			FencePresent:    data&(1<<13) != 0,
+			FenceBreached:   data&(1<<14) != 0,
+			Throttle:        float32(passthroughNumber(data>>19, 2, 0)) / 0.63,
+			IMUTemperature:  int32(data>>26&0x3F) + 19,
+		}
+
+	case PassthroughGPSStatusId:
+		return APGPSStatus{
+			Satellites:  uint8(data & 0xF),
+			FixType:     uint8(data>>4&0x3) + uint8(data>>14&0x3),
+			HDOP:        float32(passthroughNumber(data>>6&0xFF, 2, 1)) / 10,
+			AltitudeMSL: float32(passthroughNumber(data>>22, 2, 2)) / 10,
+		}
+
+	case PassthroughBattery1Id, PassthroughBattery2Id:
+		index := uint8(1)
+		if packet.AppId == PassthroughBattery2Id {
+			index = 2
+		}
+		return APBattery{
+			Index:    index,
+			Voltage:  float32(data&0x1FF) / 10,
+			Current:  float32(passthroughNumber(data>>9&0xFF, 2, 1)) / 10,
+			Consumed: data >> 17 & 0x7FFF,
+		}
+
+	case PassthroughHomeId:
+		return APHome{
+			Distance: uint32(passthroughNumber(data&0xFFF, 3, 2)),
+			Altitude: float32(passthroughNumber(data>>12, 3, 2)) / 10,
+			Bearing:  uint16(data>>25&0x7F) * 3,
+		}
+
+	case PassthroughVelocityYawId:
+		return APVelocityYaw{
+			VerticalSpeed:   float32(passthroughNumber(data, 2, 1)) / 10,
+			HorizontalSpeed: float32(passthroughNumber(data>>9&0xFF, 2, 1)) / 10,
+			Yaw:             float32(data>>17&0x7FF) * 0.2,
+			Airspeed:        data&(1<<28) != 0,
+		}
+
+	case PassthroughAttitudeRangeId:
+		return APAttitudeRange{
+			Roll:        float32(data&0x7FF)*0.2 - 180,
+			Pitch:       float32(data>>11&0x3FF)*0.2 - 90,
+			RangeFinder: float32(passthroughNumber(data>>21, 3, 1)) / 100,
+		}
+
+	case PassthroughParameterId:
+		return APParameter{
+			Id:    uint8(data >> 24),
+			Value: data & 0xFFFFFF,
+		}
+	}
+
+	return packet
+}
+
+// passthroughNumber decodes a number the way ArduPilot packs it: 7 (2 digits) or 10 (3 digits) bits of mantissa
+// after power bits holding a power of ten, followed by a sign bit. With no power bits, 2 digits are 6 bits of mantissa.
+// ArduPilot packs the next field right after the mantissa of values that are never negative, the callers mask those.
+func passthroughNumber(bits uint32, digits uint8, power uint8) int32 {
+	mantissaBits := uint8(7)
+	if digits == 3 {
+		mantissaBits = 10
+	}
+	if power == 0 {
+		mantissaBits = 6
+	}
+
+	value := int32(bits >> power & (1<<mantissaBits - 1))
+	for i := uint32(0); i < bits&(1<<power-1); i++ {
+		value *= 10
+	}
+	if bits&(1<<(power+mantissaBits)) != 0 {
+		value = -value
+	}
+	return value
+}
+
+// StatusTextAssembler joins the 4 character chunks of the status texts sent as passthrough text packets.
+// A chunk with a nul character ends the text, its top bits hold the severity. ArduPilot repeats every chunk,
+// so a chunk equal to the one before is skipped.
+type StatusTextAssembler struct {
+	text      []byte
+	lastChunk uint32
+	hasLast   bool
+}
+
+// Push adds a chunk, it returns the status text once its last chunk has been pushed
+func (a *StatusTextAssembler) Push(chunk uint32) *APStatusText {
+	if a.hasLast && chunk == a.lastChunk {
+		return nil
+	}
+	a.lastChunk = chunk
+	a.hasLast = true
+
+	for shift := 24; shift >= 0; shift -= 8 {
+		c := byte(chunk>>shift) & 0x7F
+		if c == 0 {
+			return a.finish(chunk)
+		}
+		a.text = append(a.text, c)
+	}
+
+	if len(a.text) >= MaxPassthroughTextSize {
+		//the end of the text was lost, pass on what arrived
+		return a.finish(chunk)
+	}
+	return nil
+}
+
+func (a *StatusTextAssembler) finish(chunk uint32) *APStatusText {
+	severity := uint8(chunk>>21&0x4 | chunk>>14&0x2 | chunk>>7&0x1)
+	text := &APStatusText{Severity: severity, Text: string(a.text)}
+	a.text = a.text[:0]
+	return text
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package crossfire
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPassthroughNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		bits   uint32
+		digits uint8
+		power  uint8
+		want   int32
+	}{
+		{"2 digits, no power", 37, 2, 0, 37},
+		{"2 digits, no power, largest", 0x3F, 2, 0, 63},
+		{"2 digits, no power, negative", 1<<6 | 37, 2, 0, -37},
+		{"2 digits, no power, next field ignored", 1<<7 | 5, 2, 0, 5},
+		{"2 digits, power 0 of 1", 42 << 1, 2, 1, 42},
+		{"2 digits, power 1 of 1", 100<<1 | 1, 2, 1, 1000},
+		{"2 digits, power 1 of 1, negative", 1<<8 | 42<<1 | 1, 2, 1, -420},
+		{"2 digits, power 2 of 2", 12<<2 | 2, 2, 2, 1200},
+		{"2 digits, power 2 of 2, negative", 1<<9 | 12<<2 | 1, 2, 2, -120},
+		{"3 digits, power 0 of 1", 500 << 1, 3, 1, 500},
+		{"3 digits, power 1 of 1, negative", 1<<11 | 500<<1 | 1, 3, 1, -5000},
+		{"3 digits, power 3 of 2", 1000<<2 | 3, 3, 2, 1000000},
+		{"3 digits, power 1 of 2, negative", 1<<12 | 123<<2 | 1, 3, 2, -1230},
+	}
+
+	for _, test := range tests {
+		if got := passthroughNumber(test.bits, test.digits, test.power); got != test.want {
+			t.Errorf("%s: passthroughNumber(%#x, %d, %d) is %d, want %d", test.name, test.bits, test.digits, test.power, got, test.want)
+		}
+	}
+}
+
+func TestDecodePassthroughPacket(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet PassthroughPacket
+		want   any
+	}{
+		{"status", PassthroughPacket{AppId: PassthroughAPStatusId, Data: 6 | 1<<7 | 1<<8 | 6<<26},
+			APStatus{FlightMode: 5, Flying: true, Armed: true, IMUTemperature: 25}},
+		{"status without mode", PassthroughPacket{AppId: PassthroughAPStatusId, Data: 1 << 12},
+			APStatus{Failsafe: true, IMUTemperature: 19}},
+		{"gps status, advanced fix", PassthroughPacket{AppId: PassthroughGPSStatusId, Data: 12 | 3<<4 | 12<<7 | 1<<14 | (1<<9|15<<2|1)<<22},
+			APGPSStatus{Satellites: 12, FixType: 4, HDOP: 1.2, AltitudeMSL: -15}},
+		{"battery 1", PassthroughPacket{AppId: PassthroughBattery1Id, Data: 168 | 42<<10 | 1501<<17},
+			APBattery{Index: 1, Voltage: 16.8, Current: 4.2, Consumed: 1501}},
+		{"battery 2", PassthroughPacket{AppId: PassthroughBattery2Id, Data: 120 | (12<<1|1)<<9 | 2<<17},
+			APBattery{Index: 2, Voltage: 12, Current: 12, Consumed: 2}},
+		{"home", PassthroughPacket{AppId: PassthroughHomeId, Data: 250<<2 | 1 | (35<<2|1)<<12 | 60<<25},
+			APHome{Distance: 2500, Altitude: 35, Bearing: 180}},
+		{"home below", PassthroughPacket{AppId: PassthroughHomeId, Data: 12<<2 | (1<<12|5<<2)<<12},
+			APHome{Distance: 12, Altitude: -0.5}},
+		{"velocity and yaw", PassthroughPacket{AppId: PassthroughVelocityYawId, Data: 1<<8 | 15<<1 | (25<<1|1)<<9 | 455<<17 | 1<<28},
+			APVelocityYaw{VerticalSpeed: -1.5, HorizontalSpeed: 25, Yaw: 91, Airspeed: true}},
+		{"attitude and range", PassthroughPacket{AppId: PassthroughAttitudeRangeId, Data: 900 | 450<<11 | 150<<1<<21},
+			APAttitudeRange{Roll: 0, Pitch: 0, RangeFinder: 1.5}},
+		{"parameter", PassthroughPacket{AppId: PassthroughParameterId, Data: uint32(APParamBattery1Capacity)<<24 | 5000},
+			APParameter{Id: APParamBattery1Capacity, Value: 5000}},
+		{"unknown", PassthroughPacket{AppId: 0x5123, Data: 0xDEADBEEF},
+			PassthroughPacket{AppId: 0x5123, Data: 0xDEADBEEF}},
+	}
+
+	for _, test := range tests {
+		if got := DecodePassthroughPacket(test.packet); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+// textChunk packs up to 4 characters the way ArduPilot does, with the severity in the top bits of the last chunk
+func textChunk(text string, severity uint8, last bool) uint32 {
+	var chunk uint32
+	for i := 0; i < 4; i++ {
+		chunk <<= 8
+		if i < len(text) {
+			chunk |= uint32(text[i])
+		}
+	}
+	if last {
+		chunk |= uint32(severity&0x4)<<21 | uint32(severity&0x2)<<14 | uint32(severity&0x1)<<7
+	}
+	return chunk
+}
+
+func TestStatusTextAssembler(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []uint32
+		want   []APStatusText
+	}{
+		{"ends within a chunk", []uint32{textChunk("Hell", 0, false), textChunk("o", 6, true)},
+			[]APStatusText{{Severity: 6, Text: "Hello"}}},
+		{"ends with an empty chunk", []uint32{textChunk("Warn", 0, false), textChunk("", 4, true)},
+			[]APStatusText{{Severity: 4, Text: "Warn"}}},
+		{"every severity bit", []uint32{textChunk("Ba", 7, true)},
+			[]APStatusText{{Severity: 7, Text: "Ba"}}},
+		{"emergency", []uint32{textChunk("Cras", 0, false), textChunk("h", 0, true)},
+			[]APStatusText{{Severity: 0, Text: "Crash"}}},
+		{"repeated chunks", []uint32{textChunk("Hell", 0, false), textChunk("Hell", 0, false), textChunk("o", 6, true), textChunk("o", 6, true)},
+			[]APStatusText{{Severity: 6, Text: "Hello"}}},
+		{"two texts", []uint32{textChunk("Arm", 6, true), textChunk("Disa", 0, false), textChunk("rm", 5, true)},
+			[]APStatusText{{Severity: 6, Text: "Arm"}, {Severity: 5, Text: "Disarm"}}},
+	}
+
+	for _, test := range tests {
+		var assembler StatusTextAssembler
+		var got []APStatusText
+		for _, chunk := range test.chunks {
+			if text := assembler.Push(chunk); text != nil {
+				got = append(got, *text)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStatusTextAssemblerLostEnd(t *testing.T) {
+	var assembler StatusTextAssembler
+	var text *APStatusText
+	for i := 0; text == nil && i < MaxPassthroughTextSize; i++ {
+		//alternating chunks, so that none is skipped as a repetition
+		chunk := textChunk("abcd", 0, false)
+		if i%2 == 1 {
+			chunk = textChunk("efgh", 0, false)
+		}
+		text = assembler.Push(chunk)
+	}
+
+	if text == nil {
+		t.Fatalf("text without an end is never passed on")
+	}
+	if len(text.Text) < MaxPassthroughTextSize {
+		t.Errorf("text of %d characters is passed on, want at least %d", len(text.Text), MaxPassthroughTextSize)
+	}
+}
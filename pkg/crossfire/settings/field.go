@@ -1,6 +1,9 @@
 package settings
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
 )
 
@@ -12,3 +15,82 @@ type FieldType interface {
 
 	String() string
 }
+
+// HiddenFieldFlag is set in the data type of fields the device does not want shown (e.g. not applicable to its hardware)
+const HiddenFieldFlag telemetry.CRSFFieldType = 0x80
+
+// OutOfRangeError the device has no field with the requested id
+type OutOfRangeError struct {
+	Id uint32
+}
+
+func (e *OutOfRangeError) Error() string {
+	return fmt.Sprintf("field %d is out of range", e.Id)
+}
+
+// SplitDataType separates the hidden flag from the data type of a field
+func SplitDataType(dataType telemetry.CRSFFieldType) (telemetry.CRSFFieldType, bool) {
+	return dataType &^ HiddenFieldFlag, dataType&HiddenFieldFlag != 0
+}
+
+// NewField parses the field data (starting at the name, after the parent id and data type)
+// with the constructor for its data type. The data type must not have the hidden flag set, see SplitDataType.
+func NewField(id uint32, parentId uint32, dataType telemetry.CRSFFieldType, data []uint8) (FieldType, error) {
+	var field FieldType
+	var err error
+
+	switch dataType {
+	case telemetry.CrsfUint8:
+		field, err = NewUint8Field(id, parentId, data)
+	case telemetry.CrsfInt8:
+		field, err = NewInt8Field(id, parentId, data)
+	case telemetry.CrsfUint16:
+		field, err = NewUint16Field(id, parentId, data)
+	case telemetry.CrsfInt16:
+		field, err = NewInt16Field(id, parentId, data)
+	case telemetry.CrsfUint32:
+		field, err = NewUint32Field(id, parentId, data)
+	case telemetry.CrsfInt32:
+		field, err = NewInt32Field(id, parentId, data)
+	case telemetry.CrsfUint64:
+		field, err = NewUint64Field(id, parentId, data)
+	case telemetry.CrsfInt64:
+		field, err = NewInt64Field(id, parentId, data)
+	case telemetry.CrsfFloat:
+		field, err = NewFloatField(id, parentId, data)
+	case telemetry.CrsfTextSelection:
+		field, err = NewTextSelectField(id, parentId, data)
+	case telemetry.CrsfString:
+		field, err = NewStringField(id, parentId, data)
+	case telemetry.CrsfFolder:
+		field, err = NewFolderField(id, parentId, data)
+	case telemetry.CrsfInfo:
+		field, err = NewInfoField(id, parentId, data)
+	case telemetry.CrsfCommand:
+		field, err = NewCommandField(id, parentId, data)
+	case telemetry.CrsfVtx:
+		field, err = NewVtxField(id, parentId, data)
+	case telemetry.CrsfOutOfRange:
+		return nil, &OutOfRangeError{Id: id}
+	default:
+		return nil, errors.New(fmt.Sprintf("field %d has unsupported data type %s", id, dataType))
+	}
+
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("field %d (%s) is malformed: %x. %s", id, dataType, data, err.Error()))
+	}
+	return field, nil
+}
+
+// stringEnd finds the null terminator of the string starting at start
+func stringEnd(data []uint8, start int) (int, error) {
+	if start > len(data) {
+		return 0, errors.New(fmt.Sprintf("string at %d is past the end of the %d bytes of data", start, len(data)))
+	}
+
+	end := bytes.IndexByte(data[start:], 0)
+	if end == -1 {
+		return 0, errors.New(fmt.Sprintf("string at %d is not null terminated", start))
+	}
+	return start + end, nil
+}
@@ -5,10 +5,8 @@
 package settings
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type CommandStep int32
@@ -25,7 +23,7 @@ const (
 )
 
 func (cs CommandStep) String() string {
-	names := [...]string{"foo"}
+	names := [...]string{"Idle", "Click", "Executing", "AskConfirm", "Confirmed", "Cancel", "Query"}
 	if int(cs) < len(names) {
 		return names[cs]
 	}
@@ -50,7 +48,7 @@ type CommandField struct {
 	infoEnd int
 }
 
-func NewCommandField(id uint32, parentId uint32, data []uint8) FieldType {
+func NewCommandField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/**
 	  name(string):    0 -> nameEnd
@@ -58,8 +56,14 @@ func NewCommandField(id uint32, parentId uint32, data []uint8) FieldType {
 	  timeout(uint8):  nameEnd + 2
 	  info(string):    nameEnd + 3 -> infoEnd
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	infoEnd := nameEnd + 3 + bytes.IndexByte(data[nameEnd+3:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	infoEnd, err := stringEnd(data, nameEnd+3)
+	if err != nil {
+		return nil, err
+	}
 
 	field := CommandField{
 		id,
@@ -70,7 +74,7 @@ func NewCommandField(id uint32, parentId uint32, data []uint8) FieldType {
 		infoEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *CommandField) Name() string {
@@ -93,6 +97,7 @@ func (f *CommandField) Step() CommandStep {
 	return CommandStep(f.data[f.nameEnd+1])
 }
 
+// Timeout how often to query the command while it is executing, in units of 10 milliseconds
 func (f *CommandField) Timeout() uint32 {
 	return uint32(f.data[f.nameEnd+2])
 }
@@ -102,7 +107,7 @@ func (f *CommandField) Message() string {
 }
 
 func (f *CommandField) String() string {
-	return fmt.Sprintf("(text-select) name: %s, type: %s, id: %v, pid: %v,  step: %v, tout: %v, info: %s",
+	return fmt.Sprintf("(command) name: %s, type: %s, id: %v, pid: %v,  step: %v, tout: %v, info: %s",
 		f.Name(),
 		f.Type(),
 		f.Id(),
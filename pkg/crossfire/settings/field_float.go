@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"math"
+)
+
+// FloatFieldType values are sent as int32, scaled by 10^Precision (e.g. 1.25 with precision 2 is sent as 125)
+type FloatFieldType interface {
+	FieldType
+	Value() float64
+	Min() float64
+	Max() float64
+	Default() float64
+	Precision() uint8
+	Step() float64
+	Units() string
+
+	RawValue() int32
+	RawMin() int32
+	RawMax() int32
+	RawDefault() int32
+	RawStep() int32
+}
+
+type FloatField struct {
+	id       uint32
+	parentId uint32
+	data     []uint8
+
+	//internal pointers
+	nameEnd  int
+	unitsEnd int
+}
+
+func NewFloatField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
+
+	/** name(string), value(int32), min(int32), max(int32), default(int32), precision(uint8), step(int32), units (string) **/
+	/**
+	  name(string):     0 -> nameEnd
+	  value(int32):     nameEnd + 1 -> nameEnd + 5
+	  min(int32):       nameEnd + 5 -> nameEnd + 9
+	  max(int32):       nameEnd + 9 -> nameEnd + 13
+	  default(int32):   nameEnd + 13 -> nameEnd + 17
+	  precision(uint8): nameEnd + 17
+	  step(int32):      nameEnd + 18 -> nameEnd + 22
+	  units(string):    nameEnd + 22 -> unitsEnd
+
+	**/
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+22)
+	if err != nil {
+		return nil, err
+	}
+
+	field := FloatField{
+		id,
+		parentId,
+		data,
+
+		nameEnd,
+		unitsEnd,
+	}
+
+	return &field, nil
+}
+
+func (f *FloatField) Name() string {
+	return string(f.data[0:f.nameEnd])
+}
+
+func (f *FloatField) Type() telemetry.CRSFFieldType {
+	return telemetry.CrsfFloat
+}
+
+func (f *FloatField) Id() uint32 {
+	return f.id
+}
+
+func (f *FloatField) ParentId() uint32 {
+	return f.parentId
+}
+
+func (f *FloatField) RawValue() int32 {
+	return int32(binary.BigEndian.Uint32(f.data[f.nameEnd+1 : f.nameEnd+5]))
+}
+
+func (f *FloatField) RawMin() int32 {
+	return int32(binary.BigEndian.Uint32(f.data[f.nameEnd+5 : f.nameEnd+9]))
+}
+
+func (f *FloatField) RawMax() int32 {
+	return int32(binary.BigEndian.Uint32(f.data[f.nameEnd+9 : f.nameEnd+13]))
+}
+
+func (f *FloatField) RawDefault() int32 {
+	return int32(binary.BigEndian.Uint32(f.data[f.nameEnd+13 : f.nameEnd+17]))
+}
+
+func (f *FloatField) Precision() uint8 {
+	return f.data[f.nameEnd+17]
+}
+
+func (f *FloatField) RawStep() int32 {
+	return int32(binary.BigEndian.Uint32(f.data[f.nameEnd+18 : f.nameEnd+22]))
+}
+
+func (f *FloatField) Value() float64 {
+	return f.fromRaw(f.RawValue())
+}
+
+func (f *FloatField) Min() float64 {
+	return f.fromRaw(f.RawMin())
+}
+
+func (f *FloatField) Max() float64 {
+	return f.fromRaw(f.RawMax())
+}
+
+func (f *FloatField) Default() float64 {
+	return f.fromRaw(f.RawDefault())
+}
+
+func (f *FloatField) Step() float64 {
+	return f.fromRaw(f.RawStep())
+}
+
+func (f *FloatField) Units() string {
+	return string(f.data[f.nameEnd+22 : f.unitsEnd])
+}
+
+// scale multiplies a value by 10^Precision and rounds it, the result is only sent (as int32) once it
+// is checked against RawMin and RawMax
+func (f *FloatField) scale(value float64) float64 {
+	return math.Round(value * math.Pow10(int(f.Precision())))
+}
+
+func (f *FloatField) fromRaw(raw int32) float64 {
+	return float64(raw) / math.Pow10(int(f.Precision()))
+}
+
+func (f *FloatField) String() string {
+	precision := int(f.Precision())
+	return fmt.Sprintf("(float) name: %s, type: %s, id: %v, pid: %v, val: %.*f, min: %.*f, max: %.*f, def: %.*f, step: %.*f, units: %s",
+		f.Name(),
+		f.Type(),
+		f.Id(),
+		f.ParentId(),
+		precision, f.Value(),
+		precision, f.Min(),
+		precision, f.Max(),
+		precision, f.Default(),
+		precision, f.Step(),
+		f.Units(),
+	)
+}
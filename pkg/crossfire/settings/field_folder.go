@@ -5,10 +5,8 @@
 package settings
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type FolderFieldType interface {
@@ -24,12 +22,15 @@ type FolderField struct {
 	nameEnd int
 }
 
-func NewFolderField(id uint32, parentId uint32, data []uint8) FieldType {
+func NewFolderField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/**
 	  name(string):    0 -> nameEnd
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
 
 	field := FolderField{
 		id,
@@ -39,7 +40,7 @@ func NewFolderField(id uint32, parentId uint32, data []uint8) FieldType {
 		nameEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *FolderField) Name() string {
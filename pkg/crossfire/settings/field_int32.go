@@ -5,11 +5,9 @@
 package settings
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type Int32FieldType interface {
@@ -31,7 +29,7 @@ type Int32Field struct {
 	unitsEnd int
 }
 
-func NewInt32Field(id uint32, parentId uint32, data []uint8) FieldType {
+func NewInt32Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), value(int32), min(int32), max(int32), default(int32), units (string) **/
 	/**
@@ -43,8 +41,14 @@ func NewInt32Field(id uint32, parentId uint32, data []uint8) FieldType {
 	  units(string):   nameEnd + 17 -> unitsEnd
 
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	unitsEnd := nameEnd + 17 + bytes.IndexByte(data[nameEnd+17:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+17)
+	if err != nil {
+		return nil, err
+	}
 
 	field := Int32Field{
 		id,
@@ -55,7 +59,7 @@ func NewInt32Field(id uint32, parentId uint32, data []uint8) FieldType {
 		unitsEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *Int32Field) Name() string {
@@ -63,7 +67,7 @@ func (f *Int32Field) Name() string {
 }
 
 func (f *Int32Field) Type() telemetry.CRSFFieldType {
-	return telemetry.CrsfUint32
+	return telemetry.CrsfInt32
 }
 
 func (f *Int32Field) Id() uint32 {
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+)
+
+type Int64FieldType interface {
+	FieldType
+	Value() int64
+	Min() int64
+	Max() int64
+	Default() int64
+	Units() string
+}
+
+type Int64Field struct {
+	id       uint32
+	parentId uint32
+	data     []uint8
+
+	//internal pointers
+	nameEnd  int
+	unitsEnd int
+}
+
+func NewInt64Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
+
+	/** name(string), value(int64), min(int64), max(int64), default(int64), units (string) **/
+	/**
+	  name(string):    0 -> nameEnd
+	  value(int64):    nameEnd + 1 -> nameEnd + 9
+	  min(int64):      nameEnd + 9 -> nameEnd + 17
+	  max(int64):      nameEnd + 17 -> nameEnd + 25
+	  default(int64):  nameEnd + 25 -> nameEnd + 33
+	  units(string):   nameEnd + 33 -> unitsEnd
+
+	**/
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+33)
+	if err != nil {
+		return nil, err
+	}
+
+	field := Int64Field{
+		id,
+		parentId,
+		data,
+
+		nameEnd,
+		unitsEnd,
+	}
+
+	return &field, nil
+}
+
+func (f *Int64Field) Name() string {
+	return string(f.data[0:f.nameEnd])
+}
+
+func (f *Int64Field) Type() telemetry.CRSFFieldType {
+	return telemetry.CrsfInt64
+}
+
+func (f *Int64Field) Id() uint32 {
+	return f.id
+}
+
+func (f *Int64Field) ParentId() uint32 {
+	return f.parentId
+}
+
+func (f *Int64Field) Value() int64 {
+	return int64(binary.BigEndian.Uint64(f.data[f.nameEnd+1 : f.nameEnd+9]))
+}
+
+func (f *Int64Field) Min() int64 {
+	return int64(binary.BigEndian.Uint64(f.data[f.nameEnd+9 : f.nameEnd+17]))
+}
+
+func (f *Int64Field) Max() int64 {
+	return int64(binary.BigEndian.Uint64(f.data[f.nameEnd+17 : f.nameEnd+25]))
+}
+
+func (f *Int64Field) Default() int64 {
+	return int64(binary.BigEndian.Uint64(f.data[f.nameEnd+25 : f.nameEnd+33]))
+}
+
+func (f *Int64Field) Units() string {
+	return string(f.data[f.nameEnd+33 : f.unitsEnd])
+}
+
+func (f *Int64Field) String() string {
+	return fmt.Sprintf("(int64) name: %s, type: %s, id: %v, pid: %v, val: %v, min: %v, max: %v, def: %v, units: %s",
+		f.Name(),
+		f.Type(),
+		f.Id(),
+		f.ParentId(),
+		f.Value(),
+		f.Min(),
+		f.Max(),
+		f.Default(),
+		f.Units(),
+	)
+}
@@ -5,10 +5,8 @@
 package settings
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type StringFieldType interface {
@@ -26,15 +24,21 @@ type StringField struct {
 	valueEnd int
 }
 
-func NewStringField(id uint32, parentId uint32, data []uint8) FieldType {
+func NewStringField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), value(string) **/
 	/**
 	  name(string):    0 -> nameEnd
 	  value(string): nameEnd + 1 -> valueEnd
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	valueEnd := nameEnd + 1 + bytes.IndexByte(data[nameEnd+1:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	valueEnd, err := stringEnd(data, nameEnd+1)
+	if err != nil {
+		return nil, err
+	}
 
 	field := StringField{
 		id,
@@ -45,7 +49,7 @@ func NewStringField(id uint32, parentId uint32, data []uint8) FieldType {
 		valueEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *StringField) Name() string {
@@ -5,7 +5,6 @@
 package settings
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
 	"strings"
@@ -13,7 +12,7 @@ import (
 
 type TextSelectFieldType interface {
 	FieldType
-	Options() string
+	Options() []string
 	Value() uint32
 	Min() uint32
 	Max() uint32
@@ -32,7 +31,7 @@ type TextSelectField struct {
 	unitsEnd   int
 }
 
-func NewTextSelectField(id uint32, parentId uint32, data []uint8) FieldType {
+func NewTextSelectField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), options(string), value(uint8), min(uint8), max(uint8), default(uint8), units (string) **/
 	/**
@@ -45,9 +44,18 @@ func NewTextSelectField(id uint32, parentId uint32, data []uint8) FieldType {
 	  units(string):   optionsEnd + 5 -> unitsEnd
 
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	optionsEnd := nameEnd + 1 + bytes.IndexByte(data[nameEnd+1:], 0)
-	unitsEnd := optionsEnd + 5 + bytes.IndexByte(data[optionsEnd+5:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	optionsEnd, err := stringEnd(data, nameEnd+1)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, optionsEnd+5)
+	if err != nil {
+		return nil, err
+	}
 
 	field := TextSelectField{
 		id,
@@ -59,7 +67,7 @@ func NewTextSelectField(id uint32, parentId uint32, data []uint8) FieldType {
 		unitsEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *TextSelectField) Name() string {
@@ -5,11 +5,9 @@
 package settings
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type Uint16FieldType interface {
@@ -31,7 +29,7 @@ type Uint16Field struct {
 	unitsEnd int
 }
 
-func NewUint16Field(id uint32, parentId uint32, data []uint8) FieldType {
+func NewUint16Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), value(uint16), min(uint16), max(uint16), default(uint16), units (string) **/
 	/**
@@ -43,8 +41,14 @@ func NewUint16Field(id uint32, parentId uint32, data []uint8) FieldType {
 	  units(string):   nameEnd + 9 -> unitsEnd
 
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	unitsEnd := nameEnd + 9 + bytes.IndexByte(data[nameEnd+9:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+9)
+	if err != nil {
+		return nil, err
+	}
 
 	field := Uint16Field{
 		id,
@@ -55,7 +59,7 @@ func NewUint16Field(id uint32, parentId uint32, data []uint8) FieldType {
 		unitsEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *Uint16Field) Name() string {
@@ -5,11 +5,9 @@
 package settings
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type Uint32FieldType interface {
@@ -31,7 +29,7 @@ type Uint32Field struct {
 	unitsEnd int
 }
 
-func NewUint32Field(id uint32, parentId uint32, data []uint8) FieldType {
+func NewUint32Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), value(uint32), min(uint32), max(uint32), default(uint32), units (string) **/
 	/**
@@ -43,8 +41,14 @@ func NewUint32Field(id uint32, parentId uint32, data []uint8) FieldType {
 	  units(string):   nameEnd + 17 -> unitsEnd
 
 	**/
-	nameEnd := bytes.IndexByte(data, 0)
-	unitsEnd := nameEnd + 17 + bytes.IndexByte(data[nameEnd+17:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+17)
+	if err != nil {
+		return nil, err
+	}
 
 	field := Uint32Field{
 		id,
@@ -55,7 +59,7 @@ func NewUint32Field(id uint32, parentId uint32, data []uint8) FieldType {
 		unitsEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *Uint32Field) Name() string {
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+)
+
+type Uint64FieldType interface {
+	FieldType
+	Value() uint64
+	Min() uint64
+	Max() uint64
+	Default() uint64
+	Units() string
+}
+
+type Uint64Field struct {
+	id       uint32
+	parentId uint32
+	data     []uint8
+
+	//internal pointers
+	nameEnd  int
+	unitsEnd int
+}
+
+func NewUint64Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
+
+	/** name(string), value(uint64), min(uint64), max(uint64), default(uint64), units (string) **/
+	/**
+	  name(string):    0 -> nameEnd
+	  value(uint64):   nameEnd + 1 -> nameEnd + 9
+	  min(uint64):     nameEnd + 9 -> nameEnd + 17
+	  max(uint64):     nameEnd + 17 -> nameEnd + 25
+	  default(uint64): nameEnd + 25 -> nameEnd + 33
+	  units(string):   nameEnd + 33 -> unitsEnd
+
+	**/
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+33)
+	if err != nil {
+		return nil, err
+	}
+
+	field := Uint64Field{
+		id,
+		parentId,
+		data,
+
+		nameEnd,
+		unitsEnd,
+	}
+
+	return &field, nil
+}
+
+func (f *Uint64Field) Name() string {
+	return string(f.data[0:f.nameEnd])
+}
+
+func (f *Uint64Field) Type() telemetry.CRSFFieldType {
+	return telemetry.CrsfUint64
+}
+
+func (f *Uint64Field) Id() uint32 {
+	return f.id
+}
+
+func (f *Uint64Field) ParentId() uint32 {
+	return f.parentId
+}
+
+func (f *Uint64Field) Value() uint64 {
+	return binary.BigEndian.Uint64(f.data[f.nameEnd+1 : f.nameEnd+9])
+}
+
+func (f *Uint64Field) Min() uint64 {
+	return binary.BigEndian.Uint64(f.data[f.nameEnd+9 : f.nameEnd+17])
+}
+
+func (f *Uint64Field) Max() uint64 {
+	return binary.BigEndian.Uint64(f.data[f.nameEnd+17 : f.nameEnd+25])
+}
+
+func (f *Uint64Field) Default() uint64 {
+	return binary.BigEndian.Uint64(f.data[f.nameEnd+25 : f.nameEnd+33])
+}
+
+func (f *Uint64Field) Units() string {
+	return string(f.data[f.nameEnd+33 : f.unitsEnd])
+}
+
+func (f *Uint64Field) String() string {
+	return fmt.Sprintf("(uint64) name: %s, type: %s, id: %v, pid: %v, val: %v, min: %v, max: %v, def: %v, units: %s",
+		f.Name(),
+		f.Type(),
+		f.Id(),
+		f.ParentId(),
+		f.Value(),
+		f.Min(),
+		f.Max(),
+		f.Default(),
+		f.Units(),
+	)
+}
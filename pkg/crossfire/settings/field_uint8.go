@@ -5,10 +5,8 @@
 package settings
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
-	"strings"
 )
 
 type UUint8FieldType interface {
@@ -30,7 +28,7 @@ type Uint8Field struct {
 	unitsEnd int
 }
 
-func NewUint8Field(id uint32, parentId uint32, data []uint8) FieldType {
+func NewUint8Field(id uint32, parentId uint32, data []uint8) (FieldType, error) {
 
 	/** name(string), value(int8), min(int8), max(int8), default(int8), units (string) **/
 	/**
@@ -42,8 +40,14 @@ func NewUint8Field(id uint32, parentId uint32, data []uint8) FieldType {
 	  units(string):   nameEnd + 5
 	**/
 
-	nameEnd := bytes.IndexByte(data, 0)
-	unitsEnd := nameEnd + 5 + bytes.IndexByte(data[nameEnd+5:], 0)
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unitsEnd, err := stringEnd(data, nameEnd+5)
+	if err != nil {
+		return nil, err
+	}
 
 	field := Uint8Field{
 		id,
@@ -54,7 +58,7 @@ func NewUint8Field(id uint32, parentId uint32, data []uint8) FieldType {
 		unitsEnd,
 	}
 
-	return &field
+	return &field, nil
 }
 
 func (f *Uint8Field) Name() string {
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+)
+
+// VtxFieldType the layout of the VTX data is not part of the published CRSF spec,
+// so it is kept as raw bytes and the field cannot be written
+type VtxFieldType interface {
+	FieldType
+	Data() []uint8
+}
+
+type VtxField struct {
+	id       uint32
+	parentId uint32
+	data     []uint8
+
+	//internal pointers
+	nameEnd int
+}
+
+func NewVtxField(id uint32, parentId uint32, data []uint8) (FieldType, error) {
+
+	/**
+	  name(string):    0 -> nameEnd
+	  data(bytes):     nameEnd + 1 -> end
+	**/
+	nameEnd, err := stringEnd(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	field := VtxField{
+		id,
+		parentId,
+		data,
+
+		nameEnd,
+	}
+
+	return &field, nil
+}
+
+func (f *VtxField) Name() string {
+	return string(f.data[0:f.nameEnd])
+}
+
+func (f *VtxField) Type() telemetry.CRSFFieldType {
+	return telemetry.CrsfVtx
+}
+
+func (f *VtxField) Id() uint32 {
+	return f.id
+}
+
+func (f *VtxField) ParentId() uint32 {
+	return f.parentId
+}
+
+func (f *VtxField) Data() []uint8 {
+	return f.data[f.nameEnd+1:]
+}
+
+func (f *VtxField) String() string {
+	return fmt.Sprintf("(vtx) name: %s, type: %s, id: %v, pid: %v, data: %x",
+		f.Name(),
+		f.Type(),
+		f.Id(),
+		f.ParentId(),
+		f.Data(),
+	)
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"fmt"
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+// RootFieldId the fields of a device without a parent folder belong to the root folder
+const RootFieldId uint32 = 0
+
+type Node struct {
+	Field    FieldType
+	Parent   *Node
+	Children []*Node
+
+	//Hidden the device flagged the field as not to be shown
+	Hidden bool
+}
+
+func (n *Node) IsFolder() bool {
+	_, ok := n.Field.(*FolderField)
+	return ok
+}
+
+// Child returns the direct child with the given name, or nil
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if child.Field.Name() == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path returns the names of the folders leading to the node, and the node's own name.
+// Fields naming each other as parents form a cycle, the path then stops at the first node seen twice.
+func (n *Node) Path() []string {
+	var path []string
+	visited := map[*Node]bool{}
+	for node := n; node.Parent != nil && !visited[node]; node = node.Parent {
+		visited[node] = true
+		path = append([]string{node.Field.Name()}, path...)
+	}
+	return path
+}
+
+// Tree is the parameter tree of a device, folders hold the fields that name them as their parent
+type Tree struct {
+	Root  *Node
+	nodes map[uint32]*Node
+}
+
+// NewTree arranges the fields below a root folder named after the device.
+// Fields whose parent is unknown are placed in the root folder.
+func NewTree(deviceName string, fields []FieldType) *Tree {
+	//the name is null terminated, the folder cannot be malformed
+	rootField, _ := NewFolderField(RootFieldId, RootFieldId, append([]uint8(deviceName), 0))
+	root := &Node{Field: rootField}
+	tree := &Tree{
+		Root:  root,
+		nodes: map[uint32]*Node{RootFieldId: root},
+	}
+
+	for _, field := range fields {
+		tree.nodes[field.Id()] = &Node{Field: field}
+	}
+
+	for _, field := range fields {
+		node := tree.nodes[field.Id()]
+		parent, ok := tree.nodes[field.ParentId()]
+		if !ok || parent == node {
+			parent = root
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	for _, node := range tree.nodes {
+		slices.SortFunc(node.Children, func(a, b *Node) bool {
+			return a.Field.Id() < b.Field.Id()
+		})
+	}
+
+	return tree
+}
+
+// Node returns the node of the field with the given id, or nil
+func (t *Tree) Node(id uint32) *Node {
+	return t.nodes[id]
+}
+
+// Field returns the field with the given id, or nil
+func (t *Tree) Field(id uint32) FieldType {
+	if node, ok := t.nodes[id]; ok {
+		return node.Field
+	}
+	return nil
+}
+
+// Hide marks the field with the given id as hidden
+func (t *Tree) Hide(id uint32) {
+	if node, ok := t.nodes[id]; ok {
+		node.Hidden = true
+	}
+}
+
+// Find follows the folder names from the root, e.g. Find("TX Power", "Max Power")
+func (t *Tree) Find(path ...string) *Node {
+	node := t.Root
+	for _, name := range path {
+		if node = node.Child(name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Walk visits the nodes depth first, starting with the root (at depth 0)
+func (t *Tree) Walk(visit func(node *Node, depth int)) {
+	var walk func(node *Node, depth int)
+	walk = func(node *Node, depth int) {
+		visit(node, depth)
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(t.Root, 0)
+}
+
+// Fields returns every field except the root folder, ordered by id
+func (t *Tree) Fields() []FieldType {
+	var fields []FieldType
+	for id, node := range t.nodes {
+		if id != RootFieldId {
+			fields = append(fields, node.Field)
+		}
+	}
+	slices.SortFunc(fields, func(a, b FieldType) bool {
+		return a.Id() < b.Id()
+	})
+	return fields
+}
+
+func (t *Tree) String() string {
+	var sb strings.Builder
+	t.Walk(func(node *Node, depth int) {
+		hidden := ""
+		if node.Hidden {
+			hidden = "[hidden] "
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s\n", strings.Repeat("  ", depth), hidden, node.Field))
+	})
+	return sb.String()
+}
@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package settings
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"math"
+	"strconv"
+)
+
+// MaxStringLength the longest string value that fits into a single parameter write frame
+// (64 - address, length, type, dst, src, field id, null terminator, crc)
+const MaxStringLength = 56
+
+// ValueRangeError the value is outside the field's Min and Max
+type ValueRangeError struct {
+	Field FieldType
+	Value any
+	Min   any
+	Max   any
+}
+
+func (e *ValueRangeError) Error() string {
+	return fmt.Sprintf("value %v of field %s is out of range [%v, %v]", e.Value, e.Field.Name(), e.Min, e.Max)
+}
+
+// EncodeValue validates the value against the field's range, and returns it the way parameter write frames carry it.
+// Integer fields take any integer type, float fields take a float or an integer, text selections take the option index or the option name,
+// and strings take a string.
+func EncodeValue(field FieldType, value any) ([]byte, error) {
+	switch f := field.(type) {
+	case *Uint8Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return []byte{uint8(v)}, err
+	case *Int8Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return []byte{uint8(int8(v))}, err
+	case *Uint16Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return binary.BigEndian.AppendUint16(nil, uint16(v)), err
+	case *Int16Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return binary.BigEndian.AppendUint16(nil, uint16(int16(v))), err
+	case *Uint32Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return binary.BigEndian.AppendUint32(nil, uint32(v)), err
+	case *Int32Field:
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return binary.BigEndian.AppendUint32(nil, uint32(int32(v))), err
+	case *Uint64Field:
+		v, err := checkUint64Range(field, value, f.Min(), f.Max())
+		return binary.BigEndian.AppendUint64(nil, v), err
+	case *Int64Field:
+		v, err := checkRange(field, value, f.Min(), f.Max())
+		return binary.BigEndian.AppendUint64(nil, uint64(v)), err
+	case *FloatField:
+		v, err := checkFloatRange(f, value)
+		return binary.BigEndian.AppendUint32(nil, uint32(v)), err
+	case *TextSelectField:
+		if option, ok := value.(string); ok {
+			index := slices.Index(f.Options(), option)
+			if index < 0 {
+				return nil, errors.New(fmt.Sprintf("field %s has no option %q", field.Name(), option))
+			}
+			value = index
+		}
+		v, err := checkRange(field, value, int64(f.Min()), int64(f.Max()))
+		return []byte{uint8(v)}, err
+	case *StringField:
+		v, ok := value.(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("field %s takes a string, but got %T", field.Name(), value))
+		}
+		if len(v) > MaxStringLength {
+			return nil, errors.New(fmt.Sprintf("value of field %s is %d bytes long, at most %d fit", field.Name(), len(v), MaxStringLength))
+		}
+		return append([]byte(v), 0), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("field %s (%s) cannot be written", field.Name(), field.Type()))
+	}
+}
+
+// CurrentValue returns the field's value, encoded like EncodeValue does
+func CurrentValue(field FieldType) ([]byte, error) {
+	switch f := field.(type) {
+	case *Uint8Field:
+		return EncodeValue(field, f.Value())
+	case *Int8Field:
+		return EncodeValue(field, f.Value())
+	case *Uint16Field:
+		return EncodeValue(field, f.Value())
+	case *Int16Field:
+		return EncodeValue(field, f.Value())
+	case *Uint32Field:
+		return EncodeValue(field, f.Value())
+	case *Int32Field:
+		return EncodeValue(field, f.Value())
+	case *Uint64Field:
+		return EncodeValue(field, f.Value())
+	case *Int64Field:
+		return EncodeValue(field, f.Value())
+	case *FloatField:
+		//the raw value, the device may hold one that is not a multiple of the step
+		return binary.BigEndian.AppendUint32(nil, uint32(f.RawValue())), nil
+	case *TextSelectField:
+		return []byte{uint8(f.Value())}, nil
+	case *StringField:
+		return append([]byte(f.Value()), 0), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("field %s (%s) has no value", field.Name(), field.Type()))
+	}
+}
+
+// ParseValue converts text (e.g. from the command line) into a value for EncodeValue
+func ParseValue(field FieldType, text string) (any, error) {
+	switch f := field.(type) {
+	case *StringField:
+		return text, nil
+	case *TextSelectField:
+		//option names like "25" (mW) take precedence over indexes
+		if slices.Contains(f.Options(), text) {
+			return text, nil
+		}
+		return strconv.ParseInt(text, 10, 64)
+	case *FloatField:
+		return strconv.ParseFloat(text, 64)
+	case *Uint64Field:
+		return strconv.ParseUint(text, 0, 64)
+	default:
+		return strconv.ParseInt(text, 0, 64)
+	}
+}
+
+func checkRange(field FieldType, value any, min int64, max int64) (int64, error) {
+	v, ok := toInt64(value)
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("field %s takes an integer, but got %T", field.Name(), value))
+	}
+	if v < min || v > max {
+		return 0, &ValueRangeError{Field: field, Value: value, Min: min, Max: max}
+	}
+	return v, nil
+}
+
+func checkUint64Range(field FieldType, value any, min uint64, max uint64) (uint64, error) {
+	var v uint64
+	switch u := value.(type) {
+	case uint:
+		v = uint64(u)
+	case uint64:
+		v = u
+	default:
+		i, ok := toInt64(value)
+		if !ok {
+			return 0, errors.New(fmt.Sprintf("field %s takes an integer, but got %T", field.Name(), value))
+		}
+		if i < 0 {
+			return 0, &ValueRangeError{Field: field, Value: value, Min: min, Max: max}
+		}
+		v = uint64(i)
+	}
+	if v < min || v > max {
+		return 0, &ValueRangeError{Field: field, Value: value, Min: min, Max: max}
+	}
+	return v, nil
+}
+
+// checkFloatRange returns the value scaled to the field's precision, the range is checked on the scaled value
+// so that rounding does not let values slip past Min and Max
+func checkFloatRange(field *FloatField, value any) (int32, error) {
+	var v float64
+	switch f := value.(type) {
+	case float32:
+		v = float64(f)
+	case float64:
+		v = f
+	default:
+		i, ok := toInt64(value)
+		if !ok {
+			return 0, errors.New(fmt.Sprintf("field %s takes a number, but got %T", field.Name(), value))
+		}
+		v = float64(i)
+	}
+	if math.IsNaN(v) {
+		return 0, errors.New(fmt.Sprintf("field %s cannot take NaN", field.Name()))
+	}
+
+	raw := field.scale(v)
+	if raw < float64(field.RawMin()) || raw > float64(field.RawMax()) {
+		return 0, &ValueRangeError{Field: field, Value: value, Min: field.Min(), Max: field.Max()}
+	}
+	return int32(raw), nil
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	default:
+		return 0, false
+	}
+}
@@ -104,7 +104,7 @@ type LinkStatusFlag uint32
 const (
 	StatusConnected        LinkStatusFlag = iota
 	StatusStatus1          LinkStatusFlag = iota
-	StatusModelMatch       LinkStatusFlag = iota
+	StatusModelMatch       LinkStatusFlag = iota //set by ELRS while the receiver's model id does not match
 	StatusIsArmed          LinkStatusFlag = iota
 	StatusWarning1         LinkStatusFlag = iota
 	StatusErrorConnected   LinkStatusFlag = iota
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// newBuiltinRegistry registers the decoders of the frames this package knows
+func newBuiltinRegistry() *Registry {
+	r := NewRegistry()
+
+	r.Register(crossfire.StatusFrame, decodeStatus)
+	r.Register(crossfire.ParameterSettingsEntryFrame, decodeDeviceSettingsEntry)
+	r.Register(crossfire.DeviceInfoFrame, decodeDeviceInfo)
+	r.RegisterSubType(crossfire.RadioFrame, uint8(crossfire.OpenTxSyncFrame), decodeSync)
+	r.Register(crossfire.CommandFrame, decodeCommand)
+	r.Register(crossfire.MspResponseFrame, decodeMSP)
+	r.Register(crossfire.MavlinkEnvelopeFrame, decodeMavlinkEnvelope)
+	r.Register(crossfire.DisplayPortFrame, decodeDisplayPort)
+	r.Register(crossfire.ArduPilotPassthroughFrame, decodePassthrough)
+	r.Register(crossfire.BatteryFrame, decodeBattery)
+	r.Register(crossfire.AltitudeFrame, decodeAttitude)
+	r.Register(crossfire.FlightModeFrame, decodeFlightMode)
+	r.Register(crossfire.LinkStatsFrame, decodeLinkStats)
+	r.Register(crossfire.LinkRxFrame, decodeLinkRX)
+	r.Register(crossfire.LinkTxFrame, decodeLinkTX)
+	r.Register(crossfire.GpsFrame, decodeGPS)
+	r.Register(crossfire.BaroAltFrame, decodeBarometer)
+	r.Register(crossfire.VarioFrame, decodeVariometer)
+	r.Register(crossfire.GpsTimeFrame, decodeGPSTime)
+	r.Register(crossfire.GpsExtendedFrame, decodeGPSExtended)
+	r.Register(crossfire.AirspeedFrame, decodeAirspeed)
+	r.Register(crossfire.HeartbeatFrame, decodeHeartbeat)
+	r.Register(crossfire.RpmFrame, decodeRPM)
+	r.Register(crossfire.TemperatureFrame, decodeTemperature)
+	r.Register(crossfire.CellsFrame, decodeCells)
+	r.Register(crossfire.ChannelsFrame, decodeChannels)
+	r.Register(crossfire.SubsetChannelsFrame, decodeSubsetChannels)
+
+	return r
+}
+
+func decodeStatus(data []byte) (TelemType, error) {
+	if len(data) < MinStatusFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended status telemetry frame. length is too small", data))
+	}
+	frame := StatusExtFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeDeviceSettingsEntry(data []byte) (TelemType, error) {
+	if len(data) < MinDeviceSettingsEntryFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended device entry settings telemetry frame. length is too small", data))
+	}
+	return NewDeviceSettingsEntryExtFrame(data), nil
+}
+
+func decodeDeviceInfo(data []byte) (TelemType, error) {
+	if len(data) < MinExtendedFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended device info telemetry frame. length is too small", data))
+	}
+	return NewDeviceInfoExtFrame(data), nil
+}
+
+func decodeSync(data []byte) (TelemType, error) {
+	if len(data) < SyncFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended sync telemetry frame. expected length %d, but got %d", data, SyncFrameSize, len(data)))
+	}
+	frame := SyncExtFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeCommand(data []byte) (TelemType, error) {
+	if len(data) < MinCommandFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as command frame. length is too small", data))
+	}
+	frame := CommandExtFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeMSP(data []byte) (TelemType, error) {
+	if len(data) < MinMSPFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as msp frame. length is too small", data))
+	}
+	frame := MSPExtFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeMavlinkEnvelope(data []byte) (TelemType, error) {
+	if len(data) < MinMavlinkEnvelopeFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as mavlink envelope frame. length is too small", data))
+	}
+	frame := MavlinkEnvelopeFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeDisplayPort(data []byte) (TelemType, error) {
+	if len(data) < MinDisplayPortFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as display port frame. length is too small", data))
+	}
+	frame, err := NewDisplayPortExtFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func decodePassthrough(data []byte) (TelemType, error) {
+	if len(data) < MinPassthroughFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as passthrough frame. length is too small", data))
+	}
+	frame, err := NewPassthroughFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func decodeBattery(data []byte) (TelemType, error) {
+	if len(data) != BatteryFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as battery telemetry frame. expected length %d, but got %d", data, BatteryFrameSize, len(data)))
+	}
+	frame := BatteryFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeAttitude(data []byte) (TelemType, error) {
+	if len(data) < AttitudeFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as attitude telemetry frame. expected length %d, but got %d", data, AttitudeFrameSize, len(data)))
+	}
+	frame := AttitudeFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeFlightMode(data []byte) (TelemType, error) {
+	//frame is variable size (depends on the mode)
+	frame := FlightModeFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeLinkStats(data []byte) (TelemType, error) {
+	if len(data) < LinkStatsFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-stats telemetry frame. expected length %d, but got %d", data, LinkStatsFrameSize, len(data)))
+	}
+	frame := LinkStatsFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeLinkRX(data []byte) (TelemType, error) {
+	if len(data) < LinkRXFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-rx telemetry frame. expected length %d, but got %d", data, LinkRXFrameSize, len(data)))
+	}
+	frame := LinkRXFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeLinkTX(data []byte) (TelemType, error) {
+	if len(data) < LinkTXFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-tx telemetry frame. expected length %d, but got %d", data, LinkTXFrameSize, len(data)))
+	}
+	frame := LinkTXFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeGPS(data []byte) (TelemType, error) {
+	if len(data) < GPSFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as gps telemetry frame. expected length %d, but got %d", data, GPSFrameSize, len(data)))
+	}
+	frame := GPSFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeBarometer(data []byte) (TelemType, error) {
+	if len(data) == BarometerFrameSize {
+		//TBS sends barometer data by itself
+		frame := BarometerFrame{RawData: data}
+		return &frame, nil
+	} else if len(data) >= BarometerVariometerFrameSize {
+		frame := BarometerVariometerFrame{RawData: data}
+		return &frame, nil
+	}
+	return nil, nil
+}
+
+func decodeVariometer(data []byte) (TelemType, error) {
+	//TBS sends variometer data by itself
+	if len(data) >= VariometerFrameSize {
+		frame := VariometerFrame{RawData: data}
+		return &frame, nil
+	}
+	return nil, nil
+}
+
+func decodeGPSTime(data []byte) (TelemType, error) {
+	if len(data) < GPSTimeFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as gps-time telemetry frame. expected length %d, but got %d", data, GPSTimeFrameSize, len(data)))
+	}
+	frame := GPSTimeFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeGPSExtended(data []byte) (TelemType, error) {
+	if len(data) < GPSExtendedFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as gps-extended telemetry frame. expected length %d, but got %d", data, GPSExtendedFrameSize, len(data)))
+	}
+	frame := GPSExtendedFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeAirspeed(data []byte) (TelemType, error) {
+	if len(data) < AirspeedFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as airspeed telemetry frame. expected length %d, but got %d", data, AirspeedFrameSize, len(data)))
+	}
+	frame := AirspeedFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeHeartbeat(data []byte) (TelemType, error) {
+	if len(data) < HeartbeatFrameSize {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as heartbeat telemetry frame. expected length %d, but got %d", data, HeartbeatFrameSize, len(data)))
+	}
+	frame := HeartbeatFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeRPM(data []byte) (TelemType, error) {
+	//frame is variable size, a source id followed by 24 bit values
+	if len(data) < MinRPMFrameSize || (len(data)-MinRPMFrameSize)%3 != 0 {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as rpm telemetry frame. length %d does not hold whole values", data, len(data)))
+	}
+	frame := RPMFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeTemperature(data []byte) (TelemType, error) {
+	//frame is variable size, a source id followed by 16 bit values
+	if len(data) < MinTemperatureFrameSize || (len(data)-MinTemperatureFrameSize)%2 != 0 {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as temperature telemetry frame. length %d does not hold whole values", data, len(data)))
+	}
+	frame := TemperatureFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeCells(data []byte) (TelemType, error) {
+	//frame is variable size, a source id followed by 16 bit values
+	if len(data) < MinCellsFrameSize || (len(data)-MinCellsFrameSize)%2 != 0 {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as cells telemetry frame. length %d does not hold whole values", data, len(data)))
+	}
+	frame := CellsFrame{RawData: data}
+	return &frame, nil
+}
+
+func decodeChannels(data []byte) (TelemType, error) {
+	frame, err := NewChannelsFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func decodeSubsetChannels(data []byte) (TelemType, error) {
+	frame, err := NewSubsetChannelsFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+const AirspeedFrameSize int = 6
+
+type TelemAirspeedType interface {
+	TelemType
+	Airspeed() float32 //in km/h
+}
+
+type AirspeedFrame struct {
+	RawData []uint8
+}
+
+func (t *AirspeedFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *AirspeedFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *AirspeedFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *AirspeedFrame) Airspeed() float32 {
+	return float32(binary.BigEndian.Uint16(t.RawData[3:5])) / 10 //data comes in 0.1 km/h
+}
+
+func (t *AirspeedFrame) String() string {
+	return fmt.Sprintf("(airspeed-frame) speed: %v", t.Airspeed())
+}
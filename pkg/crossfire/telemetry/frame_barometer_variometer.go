@@ -10,7 +10,7 @@ import (
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
 )
 
-const BarometerVariometerFrameSize int = 9
+const BarometerVariometerFrameSize int = 8
 
 type TelemBarometerVariometerType interface {
 	TelemType
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinCellsFrameSize address, length, type, source id, one cell voltage, crc
+const MinCellsFrameSize int = 7
+
+type TelemCellsType interface {
+	TelemType
+	SourceId() uint8
+	CellVoltages() []float32 //in volts
+}
+
+type CellsFrame struct {
+	RawData []uint8
+}
+
+func (t *CellsFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *CellsFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *CellsFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *CellsFrame) SourceId() uint8 {
+	return t.RawData[3]
+}
+
+func (t *CellsFrame) CellVoltages() []float32 {
+	values := t.RawData[4 : len(t.RawData)-1]
+	voltages := make([]float32, 0, len(values)/2)
+	for i := 0; i+2 <= len(values); i += 2 {
+		//data comes in millivolts
+		voltages = append(voltages, float32(binary.BigEndian.Uint16(values[i:i+2]))/1000)
+	}
+	return voltages
+}
+
+func (t *CellsFrame) String() string {
+	return fmt.Sprintf("(cells-frame) source: %v, cells: %v", t.SourceId(), t.CellVoltages())
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+)
+
+// TelemChannelsType the channels a handset sends to its module, seen when listening on the line between them
+type TelemChannelsType interface {
+	TelemType
+	StartChannel() int
+	Channels() []util.CRSFValue // 11-bit values, from StartChannel on
+}
+
+// ChannelsFrame the classic 16 channels frame, as created by crossfire.PackChannels
+type ChannelsFrame struct {
+	RawData []uint8
+
+	channels crossfire.Channels
+}
+
+func NewChannelsFrame(data []byte) (*ChannelsFrame, error) {
+	frame := ChannelsFrame{RawData: data}
+	if err := frame.channels.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+func (t *ChannelsFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *ChannelsFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *ChannelsFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *ChannelsFrame) StartChannel() int {
+	return 0
+}
+
+func (t *ChannelsFrame) Channels() []util.CRSFValue {
+	return t.channels.Values[:]
+}
+
+func (t *ChannelsFrame) String() string {
+	return fmt.Sprintf("(channels-frame) %v", t.channels.Values)
+}
+
+// SubsetChannelsFrame a run of channels at 10 to 13 bits, the values are scaled to 11 bits
+type SubsetChannelsFrame struct {
+	RawData []uint8
+
+	channels crossfire.SubsetChannels
+}
+
+func NewSubsetChannelsFrame(data []byte) (*SubsetChannelsFrame, error) {
+	frame := SubsetChannelsFrame{RawData: data}
+	if err := frame.channels.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+func (t *SubsetChannelsFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *SubsetChannelsFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *SubsetChannelsFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *SubsetChannelsFrame) StartChannel() int {
+	return int(t.channels.StartChannel)
+}
+
+func (t *SubsetChannelsFrame) Resolution() crossfire.ChannelResolution {
+	return t.channels.Resolution
+}
+
+func (t *SubsetChannelsFrame) Channels() []util.CRSFValue {
+	values := make([]util.CRSFValue, len(t.channels.Values))
+	for i, value := range t.channels.Values {
+		values[i] = crossfire.ScaleChannel(value, t.channels.Resolution, crossfire.Resolution11Bit)
+	}
+	return values
+}
+
+func (t *SubsetChannelsFrame) String() string {
+	return fmt.Sprintf("(subset-channels-frame) start: %d, resolution: %d, %v", t.StartChannel(), t.Resolution(), t.Channels())
+}
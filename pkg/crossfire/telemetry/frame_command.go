@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinCommandFrameSize address, length, type, dst, src, sub-command, command, crc BA, crc D5
+const MinCommandFrameSize = 9
+
+type TelemCommandExtType interface {
+	TelemExtType
+	SubCommand() crossfire.FrameType
+	Command() crossfire.FrameType
+	Payload() []uint8
+	IsSpeedResponse() bool
+	SpeedAccepted() bool
+}
+
+type CommandExtFrame struct {
+	RawData []uint8
+}
+
+func (t *CommandExtFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *CommandExtFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *CommandExtFrame) Dst() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[3])
+}
+
+func (t *CommandExtFrame) Src() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[4])
+}
+
+func (t *CommandExtFrame) Data() []uint8 {
+	return t.RawData[5:]
+}
+
+func (t *CommandExtFrame) SubCommand() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[5])
+}
+
+func (t *CommandExtFrame) Command() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[6])
+}
+
+// Payload the command arguments, without the inner crc BA and the frame crc D5
+func (t *CommandExtFrame) Payload() []uint8 {
+	return t.RawData[7 : len(t.RawData)-2]
+}
+
+// IsSpeedResponse checks if this is the module's answer to a speed proposal
+func (t *CommandExtFrame) IsSpeedResponse() bool {
+	return t.SubCommand() == crossfire.GeneralSubcommandFrame && t.Command() == crossfire.CmdSpeedResponseFrame && len(t.Payload()) >= 2
+}
+
+// SpeedAccepted only valid if IsSpeedResponse is true
+func (t *CommandExtFrame) SpeedAccepted() bool {
+	return t.Payload()[1] != 0
+}
+
+func (t *CommandExtFrame) String() string {
+	return fmt.Sprintf("(command-frame) dst: %x, src: %x, sub: %x, cmd: %x, payload: %x",
+		t.Dst(),
+		t.Src(),
+		t.SubCommand(),
+		t.Command(),
+		t.Payload())
+}
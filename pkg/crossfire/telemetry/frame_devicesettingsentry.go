@@ -9,6 +9,9 @@ import (
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
 )
 
+// MinDeviceSettingsEntryFrameSize address, length, type, dst, src, field id, chunks remaining, crc
+const MinDeviceSettingsEntryFrameSize = 8
+
 type TelemDeviceSettingsEntryExtType interface {
 	TelemExtType
 	Id() uint8
@@ -16,6 +19,7 @@ type TelemDeviceSettingsEntryExtType interface {
 	ParentId() uint8
 	DataType() CRSFFieldType
 	Buffer() []byte
+	Chunk() []byte
 }
 
 type DeviceSettingsEntryExtFrame struct {
@@ -73,6 +77,12 @@ func (t *DeviceSettingsEntryExtFrame) Buffer() []byte {
 	return t.Data()
 }
 
+// Chunk the part of the field data carried by this frame (without field id, chunks remaining and crc),
+// chunk 0 starts with the parent id and data type
+func (t *DeviceSettingsEntryExtFrame) Chunk() []byte {
+	return t.RawData[7 : len(t.RawData)-1]
+}
+
 func (t *DeviceSettingsEntryExtFrame) String() string {
 	return fmt.Sprintf("(device-settings-entry-frame) id: %v, cr: %v, pid: %v, dt: %s, clen: %v",
 		t.Id(),
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinDisplayPortFrameSize address, length, type, dst, src, sub command, crc
+const MinDisplayPortFrameSize = 7
+
+type TelemDisplayPortExtType interface {
+	TelemExtType
+	SubCommand() uint8
+	DisplayPort() *crossfire.DisplayPort
+}
+
+type DisplayPortExtFrame struct {
+	RawData []uint8
+
+	displayPort crossfire.DisplayPort
+}
+
+// NewDisplayPortExtFrame unpacks the row update (or screen size) of a display port frame
+func NewDisplayPortExtFrame(data []byte) (*DisplayPortExtFrame, error) {
+	frame := DisplayPortExtFrame{RawData: data}
+	if err := frame.displayPort.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+func (t *DisplayPortExtFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *DisplayPortExtFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *DisplayPortExtFrame) Dst() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[3])
+}
+
+func (t *DisplayPortExtFrame) Src() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[4])
+}
+
+func (t *DisplayPortExtFrame) Data() []uint8 {
+	return t.RawData[5:]
+}
+
+func (t *DisplayPortExtFrame) SubCommand() uint8 {
+	return t.RawData[5]
+}
+
+func (t *DisplayPortExtFrame) DisplayPort() *crossfire.DisplayPort {
+	return &t.displayPort
+}
+
+func (t *DisplayPortExtFrame) String() string {
+	if t.SubCommand() == crossfire.DisplayPortUpdate {
+		return fmt.Sprintf("(displayport-frame) update row %d: %q", t.displayPort.Row, t.displayPort.Text)
+	}
+	return fmt.Sprintf("(displayport-frame) dst: %x, src: %x, sub-command: %x",
+		uint8(t.Dst()),
+		uint8(t.Src()),
+		t.SubCommand(),
+	)
+}
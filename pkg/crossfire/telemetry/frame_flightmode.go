@@ -30,7 +30,7 @@ func (t *FlightModeFrame) Data() []uint8 {
 }
 
 func (t *FlightModeFrame) Mode() string {
-	return string(t.RawData[2 : len(t.RawData)-2])
+	return string(t.RawData[3 : len(t.RawData)-2])
 }
 
 func (t *FlightModeFrame) String() string {
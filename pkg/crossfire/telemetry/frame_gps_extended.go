@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+const GPSExtendedFrameSize int = 24
+
+type TelemGPSExtendedType interface {
+	TelemType
+
+	FixType() uint8
+	NorthVelocity() float32           //in meters/second
+	EastVelocity() float32            //in meters/second
+	UpVelocity() float32              //in meters/second
+	HorizontalSpeedAccuracy() float32 //in meters/second
+	TrackAccuracy() float32           //in degrees
+	AltitudeEllipsoid() int32         //in meters above the GPS ellipsoid (not MSL)
+	HorizontalAccuracy() float32      //in meters
+	VerticalAccuracy() float32        //in meters
+	HDOP() float32
+	VDOP() float32
+}
+
+type GPSExtendedFrame struct {
+	RawData []uint8
+}
+
+func (t *GPSExtendedFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *GPSExtendedFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *GPSExtendedFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *GPSExtendedFrame) int16At(offset int) int16 {
+	return int16(binary.BigEndian.Uint16(t.RawData[offset : offset+2]))
+}
+
+func (t *GPSExtendedFrame) FixType() uint8 {
+	return t.RawData[3]
+}
+
+func (t *GPSExtendedFrame) NorthVelocity() float32 {
+	return float32(t.int16At(4)) / 100 //data comes in cm/s
+}
+
+func (t *GPSExtendedFrame) EastVelocity() float32 {
+	return float32(t.int16At(6)) / 100
+}
+
+func (t *GPSExtendedFrame) UpVelocity() float32 {
+	return float32(t.int16At(8)) / 100
+}
+
+func (t *GPSExtendedFrame) HorizontalSpeedAccuracy() float32 {
+	return float32(t.int16At(10)) / 100
+}
+
+func (t *GPSExtendedFrame) TrackAccuracy() float32 {
+	return float32(t.int16At(12)) / 10 //data comes in 0.1 degrees
+}
+
+func (t *GPSExtendedFrame) AltitudeEllipsoid() int32 {
+	return int32(t.int16At(14))
+}
+
+func (t *GPSExtendedFrame) HorizontalAccuracy() float32 {
+	return float32(t.int16At(16)) / 100 //data comes in cm
+}
+
+func (t *GPSExtendedFrame) VerticalAccuracy() float32 {
+	return float32(t.int16At(18)) / 100
+}
+
+func (t *GPSExtendedFrame) HDOP() float32 {
+	//byte 20 is reserved
+	return float32(t.RawData[21]) / 10
+}
+
+func (t *GPSExtendedFrame) VDOP() float32 {
+	return float32(t.RawData[22]) / 10
+}
+
+func (t *GPSExtendedFrame) String() string {
+	return fmt.Sprintf("(gps-extended-frame) fix: %v, vel(n/e/u): %v/%v/%v, sacc: %v, tacc: %v, alt-ell: %v, hacc: %v, vacc: %v, hdop: %v, vdop: %v",
+		t.FixType(),
+		t.NorthVelocity(),
+		t.EastVelocity(),
+		t.UpVelocity(),
+		t.HorizontalSpeedAccuracy(),
+		t.TrackAccuracy(),
+		t.AltitudeEllipsoid(),
+		t.HorizontalAccuracy(),
+		t.VerticalAccuracy(),
+		t.HDOP(),
+		t.VDOP(),
+	)
+}
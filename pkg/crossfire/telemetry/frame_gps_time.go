@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+const GPSTimeFrameSize int = 13
+
+type TelemGPSTimeType interface {
+	TelemType
+	GPSTime() time.Time //in UTC
+}
+
+type GPSTimeFrame struct {
+	RawData []uint8
+}
+
+func (t *GPSTimeFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *GPSTimeFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *GPSTimeFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *GPSTimeFrame) GPSTime() time.Time {
+	return time.Date(
+		int(int16(binary.BigEndian.Uint16(t.RawData[3:5]))),
+		time.Month(t.RawData[5]),
+		int(t.RawData[6]),
+		int(t.RawData[7]),
+		int(t.RawData[8]),
+		int(t.RawData[9]),
+		int(binary.BigEndian.Uint16(t.RawData[10:12]))*int(time.Millisecond),
+		time.UTC,
+	)
+}
+
+func (t *GPSTimeFrame) String() string {
+	return fmt.Sprintf("(gps-time-frame) time: %v", t.GPSTime().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+const HeartbeatFrameSize int = 6
+
+type TelemHeartbeatType interface {
+	TelemType
+	Origin() crossfire.Endpoint
+}
+
+type HeartbeatFrame struct {
+	RawData []uint8
+}
+
+func (t *HeartbeatFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *HeartbeatFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *HeartbeatFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *HeartbeatFrame) Origin() crossfire.Endpoint {
+	return crossfire.Endpoint(binary.BigEndian.Uint16(t.RawData[3:5]))
+}
+
+func (t *HeartbeatFrame) String() string {
+	return fmt.Sprintf("(heartbeat-frame) origin: %v", t.Origin())
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinMavlinkEnvelopeFrameSize address, length, type, chunk counts, data size, crc
+const MinMavlinkEnvelopeFrameSize = 6
+
+type TelemMavlinkEnvelopeType interface {
+	TelemType
+	TotalChunks() uint8
+	CurrentChunk() uint8
+	Payload() []uint8
+	Envelope() *crossfire.MavlinkEnvelope
+}
+
+type MavlinkEnvelopeFrame struct {
+	RawData []uint8
+}
+
+func (t *MavlinkEnvelopeFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+
+func (t *MavlinkEnvelopeFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *MavlinkEnvelopeFrame) Data() []uint8 {
+	return t.RawData[3:]
+}
+
+func (t *MavlinkEnvelopeFrame) TotalChunks() uint8 {
+	return t.RawData[3] & 0x0F
+}
+
+func (t *MavlinkEnvelopeFrame) CurrentChunk() uint8 {
+	return t.RawData[3] >> 4
+}
+
+// Payload the MAVLink bytes, limited to the frame in case the data size is too large
+func (t *MavlinkEnvelopeFrame) Payload() []uint8 {
+	end := min(5+int(t.RawData[4]), len(t.RawData)-1)
+	return t.RawData[5:end]
+}
+
+// Envelope copies the frame into the codec type, which MAVLink streams are assembled from
+func (t *MavlinkEnvelopeFrame) Envelope() *crossfire.MavlinkEnvelope {
+	return &crossfire.MavlinkEnvelope{
+		Addr:         t.Addr(),
+		TotalChunks:  t.TotalChunks(),
+		CurrentChunk: t.CurrentChunk(),
+		Data:         append([]byte(nil), t.Payload()...),
+	}
+}
+
+func (t *MavlinkEnvelopeFrame) String() string {
+	return fmt.Sprintf("(mavlink-envelope-frame) chunk: %d/%d, data: %x",
+		t.CurrentChunk()+1,
+		t.TotalChunks(),
+		t.Payload(),
+	)
+}
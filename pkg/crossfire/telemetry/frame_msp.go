@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinMSPFrameSize address, length, type, dst, src, status, crc
+const MinMSPFrameSize = 7
+
+type TelemMSPExtType interface {
+	TelemExtType
+	Status() uint8
+	Chunk() []uint8
+	MSPFrame() *crossfire.MSP
+}
+
+type MSPExtFrame struct {
+	RawData []uint8
+}
+
+func (t *MSPExtFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *MSPExtFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *MSPExtFrame) Dst() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[3])
+}
+
+func (t *MSPExtFrame) Src() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[4])
+}
+
+func (t *MSPExtFrame) Data() []uint8 {
+	return t.RawData[5:]
+}
+
+func (t *MSPExtFrame) Status() uint8 {
+	return t.RawData[5]
+}
+
+// Chunk the part of the MSP packet carried by this frame, without the status byte and the crc
+func (t *MSPExtFrame) Chunk() []uint8 {
+	return t.RawData[6 : len(t.RawData)-1]
+}
+
+// MSPFrame copies the frame into the codec type, which MSP packets are assembled from
+func (t *MSPExtFrame) MSPFrame() *crossfire.MSP {
+	return &crossfire.MSP{
+		Addr:      t.Addr(),
+		FrameType: t.Type(),
+		Dst:       t.Dst(),
+		Src:       t.Src(),
+		Status:    t.Status(),
+		Chunk:     append([]byte(nil), t.Chunk()...),
+	}
+}
+
+func (t *MSPExtFrame) String() string {
+	return fmt.Sprintf("(msp-frame) type: %x, dst: %x, src: %x, status: %x, chunk: %x",
+		uint8(t.Type()),
+		uint8(t.Dst()),
+		uint8(t.Src()),
+		t.Status(),
+		t.Chunk(),
+	)
+}
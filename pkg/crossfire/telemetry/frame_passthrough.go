@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinPassthroughFrameSize address, length, type, sub type, crc
+const MinPassthroughFrameSize = 5
+
+type TelemPassthroughType interface {
+	TelemType
+	SubType() uint8
+	Passthrough() *crossfire.Passthrough
+}
+
+type PassthroughFrame struct {
+	RawData []uint8
+
+	passthrough crossfire.Passthrough
+}
+
+// NewPassthroughFrame unpacks the packets (or status text) of an ArduPilot passthrough frame
+func NewPassthroughFrame(data []byte) (*PassthroughFrame, error) {
+	frame := PassthroughFrame{RawData: data}
+	if err := frame.passthrough.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+func (t *PassthroughFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *PassthroughFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *PassthroughFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *PassthroughFrame) SubType() uint8 {
+	return t.RawData[3]
+}
+
+func (t *PassthroughFrame) Passthrough() *crossfire.Passthrough {
+	return &t.passthrough
+}
+
+func (t *PassthroughFrame) String() string {
+	if t.passthrough.SubType == crossfire.PassthroughStatusText {
+		return fmt.Sprintf("(passthrough-frame) severity: %d, text: %q", t.passthrough.Severity, t.passthrough.Text)
+	}
+	return fmt.Sprintf("(passthrough-frame) sub-type: %x, packets: %x", t.SubType(), t.passthrough.Packets)
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// RawFrame is a frame no decoder is registered for, it is passed on as received
+type RawFrame struct {
+	RawData []uint8
+}
+
+func (t *RawFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *RawFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *RawFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+// Payload the bytes between the frame type and the crc
+func (t *RawFrame) Payload() []uint8 {
+	return t.RawData[3 : len(t.RawData)-1]
+}
+
+func (t *RawFrame) String() string {
+	return fmt.Sprintf("(raw-frame) addr: %x, type: %x, payload: %x", uint8(t.Addr()), uint8(t.Type()), t.Payload())
+}
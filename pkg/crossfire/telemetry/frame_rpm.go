@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinRPMFrameSize address, length, type, source id, one 24 bit value, crc
+const MinRPMFrameSize int = 8
+
+type TelemRPMType interface {
+	TelemType
+	SourceId() uint8
+	RPMs() []int32 //in revolutions per minute
+}
+
+type RPMFrame struct {
+	RawData []uint8
+}
+
+func (t *RPMFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *RPMFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *RPMFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *RPMFrame) SourceId() uint8 {
+	return t.RawData[3]
+}
+
+func (t *RPMFrame) RPMs() []int32 {
+	values := t.RawData[4 : len(t.RawData)-1]
+	rpms := make([]int32, 0, len(values)/3)
+	for i := 0; i+3 <= len(values); i += 3 {
+		//data comes as 24 bit signed, shift into the top of an int32 and back to extend the sign
+		rpms = append(rpms, int32(uint32(values[i])<<24|uint32(values[i+1])<<16|uint32(values[i+2])<<8)>>8)
+	}
+	return rpms
+}
+
+func (t *RPMFrame) String() string {
+	return fmt.Sprintf("(rpm-frame) source: %v, rpm: %v", t.SourceId(), t.RPMs())
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
 )
 
+// MinStatusFrameSize address, length, type, dst, src, bad packets, good packets (2), flags, crc
+const MinStatusFrameSize = 10
+
 type TelemStatusExtType interface {
 	TelemExtType
 
@@ -48,7 +51,7 @@ func (t *StatusExtFrame) Data() []uint8 {
 }
 
 func (t *StatusExtFrame) BadPackets() uint32 {
-	return uint32(t.RawData[6])
+	return uint32(t.RawData[5])
 }
 
 func (t *StatusExtFrame) GoodPackets() uint32 {
@@ -56,7 +59,9 @@ func (t *StatusExtFrame) GoodPackets() uint32 {
 }
 
 func (t *StatusExtFrame) Flags() []LinkStatusFlag {
-	//fmt.Printf("%x", t.RawData)
+	if len(t.RawData) < 9 {
+		return nil
+	}
 	flags := t.RawData[8]
 	var res []LinkStatusFlag
 	for i := 7; i >= 0; i-- {
@@ -67,8 +72,17 @@ func (t *StatusExtFrame) Flags() []LinkStatusFlag {
 	}
 	return res
 }
+
+// Message returns the null terminated text after the flags, or the text up to the crc if the terminator is missing
 func (t *StatusExtFrame) Message() string {
-	return string(t.RawData[9 : 9+bytes.IndexByte(t.RawData[9:], 0)])
+	if len(t.RawData) < 10 {
+		return ""
+	}
+	text := t.RawData[9 : len(t.RawData)-1]
+	if end := bytes.IndexByte(text, 0); end >= 0 {
+		text = text[:end]
+	}
+	return string(text)
 }
 
 func (t *StatusExtFrame) String() string {
@@ -11,6 +11,9 @@ import (
 	"time"
 )
 
+// SyncFrameSize address, length, type, dst, src, sub type, rate (4), offset (4), crc
+const SyncFrameSize = 15
+
 type TelemSyncType interface {
 	TelemExtType
 	Rate() int32
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// MinTemperatureFrameSize address, length, type, source id, one temperature, crc
+const MinTemperatureFrameSize int = 7
+
+type TelemTemperatureType interface {
+	TelemType
+	SourceId() uint8
+	Temperatures() []float32 //in degrees celsius
+}
+
+type TemperatureFrame struct {
+	RawData []uint8
+}
+
+func (t *TemperatureFrame) Addr() crossfire.Endpoint {
+	return crossfire.Endpoint(t.RawData[0])
+}
+func (t *TemperatureFrame) Type() crossfire.FrameType {
+	return crossfire.FrameType(t.RawData[2])
+}
+
+func (t *TemperatureFrame) Data() []uint8 {
+	return t.RawData[2:]
+}
+
+func (t *TemperatureFrame) SourceId() uint8 {
+	return t.RawData[3]
+}
+
+func (t *TemperatureFrame) Temperatures() []float32 {
+	values := t.RawData[4 : len(t.RawData)-1]
+	temperatures := make([]float32, 0, len(values)/2)
+	for i := 0; i+2 <= len(values); i += 2 {
+		//data comes in 0.1 degrees celsius
+		temperatures = append(temperatures, float32(int16(binary.BigEndian.Uint16(values[i:i+2])))/10)
+	}
+	return temperatures
+}
+
+func (t *TemperatureFrame) String() string {
+	return fmt.Sprintf("(temperature-frame) source: %v, temp: %v", t.SourceId(), t.Temperatures())
+}
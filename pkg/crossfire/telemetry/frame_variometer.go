@@ -10,7 +10,7 @@ import (
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
 )
 
-const VariometerFrameSize int = 7
+const VariometerFrameSize int = 6
 
 type TelemVariometerType interface {
 	TelemType
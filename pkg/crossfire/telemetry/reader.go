@@ -18,7 +18,12 @@ import (
 type Reader struct {
 	Buffer []uint8
 	Frame  *[]uint8
-	Port   *serial.Port
+	Port   serial.Transport
+
+	// IsFrameStart accepts the first byte of a frame, frames to the handset and module by default
+	IsFrameStart func(crossfire.Endpoint) bool
+	// Decode turns the frames into telemetry, Unmarshal by default
+	Decode func([]byte) (TelemType, error)
 
 	start           int
 	end             int
@@ -28,11 +33,13 @@ type Reader struct {
 	eof             bool
 }
 
-func NewReader(port *serial.Port) *Reader {
+func NewReader(port serial.Transport) *Reader {
 	capacity := 1024
 	return &Reader{
 		Buffer:          make([]uint8, capacity),
 		Port:            port,
+		IsFrameStart:    isTelemetryAddress,
+		Decode:          Unmarshal,
 		start:           0,
 		end:             0,
 		initialCapacity: capacity,
@@ -54,6 +61,31 @@ func (s *Reader) Next(tomb *tomb.Tomb) (TelemType, error) {
 
 	for {
 
+		//drain frames that are already buffered before blocking on the port
+		if s.start < s.end {
+			skip, frame, err = SplitAt(s.Buffer[s.start:s.end], s.eof, s.IsFrameStart)
+			s.start += skip
+
+			if frame != nil {
+				if tmp, err = s.Decode(*frame); err != nil {
+					return nil, err
+				} else if tmp == nil {
+					//unknown telemetry frame, ignore it
+					continue
+				}
+
+				return tmp, nil
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if skip > 0 {
+				continue
+			}
+		}
+
 		if s.start >= s.end {
 			s.start = 0
 			s.end = 0
@@ -85,36 +117,30 @@ func (s *Reader) Next(tomb *tomb.Tomb) (TelemType, error) {
 
 		s.end += count
 		if s.end == s.currentCapacity {
-			//fmt.Printf("doubling capacity, start: %v, end: %v\n", s.start, s.end)
-			newCap = s.currentCapacity * 2
-			newBuf = make([]uint8, newCap)
-			copy(newBuf, s.Buffer)
-			s.Buffer = newBuf
-			s.currentCapacity = newCap
-		}
-
-		skip, frame, err = Split(s.Buffer[s.start:s.end], s.eof)
-		s.start += skip
-
-		if frame != nil {
-			if tmp, err = Unmarshal(*frame); err != nil {
-				return nil, err
-			} else if tmp == nil {
-				//unknown telemetry frame, ignore it
-				continue
+			if s.start > 0 {
+				//move the partial frame to the front of the buffer
+				copy(s.Buffer, s.Buffer[s.start:s.end])
+				s.end -= s.start
+				s.start = 0
+			} else {
+				//fmt.Printf("doubling capacity, start: %v, end: %v\n", s.start, s.end)
+				newCap = s.currentCapacity * 2
+				newBuf = make([]uint8, newCap)
+				copy(newBuf, s.Buffer)
+				s.Buffer = newBuf
+				s.currentCapacity = newCap
 			}
-
-			return tmp, nil
-		}
-
-		if err != nil {
-			return nil, err
 		}
 	}
 
 }
 
 func Split(data []byte, atEOF bool) (advance int, token *[]byte, err error) {
+	return SplitAt(data, atEOF, isTelemetryAddress)
+}
+
+// SplitAt works like Split, but frames start at any byte accepted by isFrameStart
+func SplitAt(data []byte, atEOF bool, isFrameStart func(crossfire.Endpoint) bool) (advance int, token *[]byte, err error) {
 	dataLen := int32(len(data))
 
 	//fmt.Printf("split: eof: %v, len: %d, data: %x\n", atEOF, dataLen, data)
@@ -123,7 +149,7 @@ func Split(data []byte, atEOF bool) (advance int, token *[]byte, err error) {
 	}
 
 	frameStart := int32(slices.IndexFunc(data, func(c byte) bool {
-		return isTelemetryAddress(crossfire.Endpoint(c))
+		return isFrameStart(crossfire.Endpoint(c))
 	}))
 
 	//fmt.Printf("frameStart: %d\n", frameStart)
@@ -176,95 +202,11 @@ func Split(data []byte, atEOF bool) (advance int, token *[]byte, err error) {
 	return int(skip), &frame, nil
 }
 
+// Unmarshal decodes a frame sent to the handset with the DefaultRegistry, frames sent anywhere else are returned as a RawFrame
 func Unmarshal(data []byte) (TelemType, error) {
-	if len(data) < 3 {
-		return nil, errors.New("cannot unmarshal %x as telemetry frame. length is too small")
-	}
-
-	fAddr := crossfire.Endpoint(data[0])
-	fType := crossfire.FrameType(data[2])
-
-	if fAddr == crossfire.HandsetEndpoint && fType == crossfire.StatusFrame {
-		frame := StatusExtFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.ParameterSettingsEntryFrame {
-		if len(data) < MinExtendedFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended device entry settings telemetry frame. length is too small", data))
-		}
-		return NewDeviceSettingsEntryExtFrame(data), nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.DeviceInfoFrame {
-		if len(data) < MinExtendedFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended device info telemetry frame. length is too small", data))
-		}
-		return NewDeviceInfoExtFrame(data), nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.RadioFrame {
-		if len(data) < MinExtendedFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as extended radio telemetry frame. length is too small", data))
-		}
-		fExtType := crossfire.FrameType(data[5])
-		if fExtType == crossfire.OpenTxSyncFrame {
-			frame := SyncExtFrame{RawData: data}
-			return &frame, nil
-		}
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.BatteryFrame {
-		if len(data) != BatteryFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as battery telemetry frame. expected length %d, but got %d", data, BatteryFrameSize, len(data)))
-		}
-		frame := BatteryFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.AltitudeFrame {
-		if len(data) < AttitudeFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as attitude telemetry frame. expected length %d, but got %d", data, AttitudeFrameSize, len(data)))
-		}
-		frame := AttitudeFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.FlightModeFrame {
-		//frame is variable size (depends on the mode)
-		frame := FlightModeFrame{RawData: data}
+	if len(data) >= 4 && crossfire.Endpoint(data[0]) != crossfire.HandsetEndpoint {
+		frame := RawFrame{RawData: data}
 		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.LinkStatsFrame {
-		if len(data) < LinkStatsFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-stats telemetry frame. expected length %d, but got %d", data, LinkStatsFrameSize, len(data)))
-		}
-		frame := LinkStatsFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.LinkRxFrame {
-		if len(data) < LinkRXFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-rx telemetry frame. expected length %d, but got %d", data, LinkRXFrameSize, len(data)))
-		}
-		frame := LinkRXFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.LinkTxFrame {
-		if len(data) < LinkTXFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as link-tx telemetry frame. expected length %d, but got %d", data, LinkTXFrameSize, len(data)))
-		}
-		frame := LinkTXFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.GpsFrame {
-		if len(data) < GPSFrameSize {
-			return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as gps telemetry frame. expected length %d, but got %d", data, GPSFrameSize, len(data)))
-		}
-		frame := GPSFrame{RawData: data}
-		return &frame, nil
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.BaroAltFrame {
-		if len(data) == BarometerFrameSize {
-			//TBS sends barometer data by itself
-			frame := BarometerFrame{RawData: data}
-			return &frame, nil
-		} else if len(data) == BarometerVariometerFrameSize {
-			frame := BarometerVariometerFrame{RawData: data}
-			return &frame, nil
-		}
-	} else if fAddr == crossfire.HandsetEndpoint && fType == crossfire.VarioFrame {
-		//TBS sends variometer data by itself
-		if len(data) == VariometerFrameSize {
-			frame := VariometerFrame{RawData: data}
-			return &frame, nil
-		}
-	} else {
-		fmt.Printf("(recv-loop) unknown frame: %x\n", data)
 	}
-
-	//unknown telemetry frame, ignore it
-	return nil, nil
+	return DefaultRegistry.Decode(data)
 }
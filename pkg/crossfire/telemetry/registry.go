@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"sync"
+)
+
+// Decoder turns a whole frame (address to crc) into its telemetry type. It returns an error for frames
+// that are malformed, and nil (without an error) for frames it does not handle, which become a RawFrame.
+type Decoder func(data []byte) (TelemType, error)
+
+type subTypeKey struct {
+	frameType crossfire.FrameType
+	subType   uint8
+}
+
+// Registry holds the decoders of frame types, and of sub types of extended frames
+type Registry struct {
+	mutex           sync.RWMutex
+	decoders        map[crossfire.FrameType]Decoder
+	subTypeDecoders map[subTypeKey]Decoder
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		decoders:        map[crossfire.FrameType]Decoder{},
+		subTypeDecoders: map[subTypeKey]Decoder{},
+	}
+}
+
+// DefaultRegistry decodes the frames of Unmarshal and of readers, it starts out with the built-in decoders.
+// Applications add decoders for vendor frames with RegisterDecoder and RegisterSubTypeDecoder.
+var DefaultRegistry = newBuiltinRegistry()
+
+// RegisterDecoder registers a decoder for a frame type with the DefaultRegistry
+func RegisterDecoder(fType crossfire.FrameType, decoder Decoder) {
+	DefaultRegistry.Register(fType, decoder)
+}
+
+// RegisterSubTypeDecoder registers a decoder for a sub type of an extended frame type with the DefaultRegistry
+func RegisterSubTypeDecoder(fType crossfire.FrameType, subType uint8, decoder Decoder) {
+	DefaultRegistry.RegisterSubType(fType, subType, decoder)
+}
+
+// Register sets the decoder of a frame type, replacing any decoder registered before. A nil decoder removes it.
+func (r *Registry) Register(fType crossfire.FrameType, decoder Decoder) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if decoder == nil {
+		delete(r.decoders, fType)
+		return
+	}
+	r.decoders[fType] = decoder
+}
+
+// RegisterSubType sets the decoder of a sub type of an extended frame type, the sub type is the first byte
+// after the destination and source. Sub type decoders are tried before the decoder of the frame type.
+func (r *Registry) RegisterSubType(fType crossfire.FrameType, subType uint8, decoder Decoder) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := subTypeKey{frameType: fType, subType: subType}
+	if decoder == nil {
+		delete(r.subTypeDecoders, key)
+		return
+	}
+	r.subTypeDecoders[key] = decoder
+}
+
+// Decode hands a frame to its decoder, frames without a decoder are returned as a RawFrame
+func (r *Registry) Decode(data []byte) (TelemType, error) {
+	if len(data) < 4 {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %x as telemetry frame. length is too small", data))
+	}
+
+	fType := crossfire.FrameType(data[2])
+
+	r.mutex.RLock()
+	var decoder Decoder
+	//address, length, type, dst, src, sub type, crc
+	if len(data) > MinExtendedFrameSize {
+		decoder = r.subTypeDecoders[subTypeKey{frameType: fType, subType: data[5]}]
+	}
+	if decoder == nil {
+		decoder = r.decoders[fType]
+	}
+	r.mutex.RUnlock()
+
+	if decoder != nil {
+		frame, err := decoder(data)
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+
+	frame := RawFrame{RawData: data}
+	return &frame, nil
+}
@@ -13,6 +13,11 @@ func isTelemetryAddress(c crossfire.Endpoint) bool {
 	return c == crossfire.HandsetEndpoint || c == crossfire.ModuleEndpoint
 }
 
+// IsSyncAddress accepts the first byte of frames going in either direction
+func IsSyncAddress(c crossfire.Endpoint) bool {
+	return isTelemetryAddress(c) || c == crossfire.Endpoint(crossfire.UartSyncFrame) || c == crossfire.ReceiverEndpoint
+}
+
 func BarometerAltitude(data []byte) float32 {
 	raw := binary.BigEndian.Uint16(data)
 	if raw&0x8000 == 0x8000 { //high bit is set, measurement is in meters
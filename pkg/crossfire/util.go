@@ -5,102 +5,53 @@
 package crossfire
 
 import (
-	"github.com/kaack/elrs-joystick-control/pkg/crc"
 	"github.com/kaack/elrs-joystick-control/pkg/util"
 	"time"
 )
 
 func CreateModelIDFrame(modelId uint8) []uint8 {
-	frame := []uint8{
-		/* 0: */ uint8(UartSyncFrame),
-		/* 1: */ 8,
-		/* 2: */ uint8(CommandFrame),
-		/* 3: */ uint8(ModuleEndpoint),
-		/* 4: */ uint8(HandsetEndpoint),
-		/* 5: */ uint8(SubcommandFrame),
-		/* 6: */ uint8(CmdModelSelectFrame),
-		/* 7: */ modelId, //model id
-		/* 8: */ 0, //crc BA
-		/* 9: */ 0, //crc D5
-	}
+	return NewModelSelectCommand(modelId).Marshal()
+}
 
-	frame[8] = crc.BA(frame[2:8])
-	frame[9] = crc.D5(frame[2:9])
-	return frame
+// CreateSpeedProposalFrame asks the module to switch the CRSF port (portId 0 is the handset port) to a new baud rate
+func CreateSpeedProposalFrame(portId uint8, baudRate int32) []uint8 {
+	return NewSpeedProposalCommand(portId, baudRate).Marshal()
 }
 
 func CreatePingDevicesFrame() []uint8 {
-	frame := []uint8{
-		/* 0: */ uint8(UartSyncFrame),
-		/* 1: */ 5,
-		/* 2: */ uint8(PingDevicesFrame),
-		/* 3: */ uint8(AllEndpoint),
-		/* 4: */ uint8(LuaEndpoint),
-		/* 5: */ 0, //crc BA
-		/* 6: */ 0, //crc D5
-	}
-
-	frame[5] = crc.BA(frame[2:5])
-	frame[6] = crc.D5(frame[2:6])
-	return frame
+	frame := PingDevices{Addr: Endpoint(UartSyncFrame), Dst: AllEndpoint, Src: LuaEndpoint}
+	return frame.Marshal()
 }
 
 func CreateParameterSettingsReadFrame(deviceId uint8, fieldId uint8, fieldChunk uint8) []uint8 {
-	frame := []uint8{
-		/* 0: */ uint8(UartSyncFrame),
-		/* 1: */ 7,
-		/* 2: */ uint8(ParameterSettingsReadFrame),
-		/* 3: */ deviceId,
-		/* 4: */ uint8(LuaEndpoint),
-		/* 5: */ fieldId,
-		/* 6: */ fieldChunk,
-		/* 7: */ 0, //crc BA
-		/* 8: */ 0, //crc D5
+	frame := ParameterRead{
+		Addr:       Endpoint(UartSyncFrame),
+		Dst:        Endpoint(deviceId),
+		Src:        LuaEndpoint,
+		FieldId:    fieldId,
+		ChunkIndex: fieldChunk,
 	}
-
-	frame[7] = crc.BA(frame[2:7])
-	frame[8] = crc.D5(frame[2:8])
-	return frame
+	return frame.Marshal()
 }
 
 func CreateParameterSettingWriteFrameUint8(deviceId uint8, fieldId uint8, fieldValue uint8) []uint8 {
-	frame := []uint8{
-		/* 0: */ uint8(UartSyncFrame),
-		/* 1: */ 7,
-		/* 2: */ uint8(ParameterSettingsWriteFrame),
-		/* 3: */ deviceId,
-		/* 4: */ uint8(LuaEndpoint),
-		/* 5: */ fieldId,
-		/* 6: */ fieldValue,
-		/* 7: */ 0, //crc BA
-		/* 8: */ 0, //crc D5
-	}
-
-	frame[7] = crc.BA(frame[2:7])
-	frame[8] = crc.D5(frame[2:8])
-	//fmt.Printf("%x\n", frame)
-	return frame
+	return CreateParameterSettingWriteFrame(deviceId, fieldId, []uint8{fieldValue})
 }
 
-// CreateParameterSettingWriteFrameUint16
-// not sure if this would work, ELRS seems to throw away the least-significant-byte of the fieldValue
 func CreateParameterSettingWriteFrameUint16(deviceId uint8, fieldId uint8, fieldValue uint16) []uint8 {
-	frame := []uint8{
-		/* 0: */ uint8(UartSyncFrame),
-		/* 1: */ 8,
-		/* 2: */ uint8(ParameterSettingsWriteFrame),
-		/* 3: */ deviceId,
-		/* 4: */ uint8(LuaEndpoint),
-		/* 5: */ fieldId,
-		/* 6: */ uint8((fieldValue >> 8) & 0x0F),
-		/* 7: */ uint8(fieldValue & 0x0F),
-		/* 8: */ 0, //crc BA
-		/* 9: */ 0, //crc D5
-	}
+	return CreateParameterSettingWriteFrame(deviceId, fieldId, []uint8{uint8(fieldValue >> 8), uint8(fieldValue)})
+}
 
-	frame[8] = crc.BA(frame[2:8])
-	frame[9] = crc.D5(frame[2:9])
-	return frame
+// CreateParameterSettingWriteFrame writes an already encoded (big-endian) value
+func CreateParameterSettingWriteFrame(deviceId uint8, fieldId uint8, fieldValue []uint8) []uint8 {
+	frame := ParameterWrite{
+		Addr:    Endpoint(UartSyncFrame),
+		Dst:     Endpoint(deviceId),
+		Src:     LuaEndpoint,
+		FieldId: fieldId,
+		Value:   fieldValue,
+	}
+	return frame.Marshal()
 }
 
 func GetRefreshRate(baudRate int32) time.Duration {
@@ -117,38 +68,17 @@ func GetRefreshRate(baudRate int32) time.Duration {
 }
 
 func PackChannels(channels *[16]util.CRSFValue) (result []byte) {
-	var CrossfireChBits uint8 = 11
-	var buf [26]byte
-	var offset uint8 = 0
-	var bits util.CRSFValue
-	var bitsAvailable uint8 = 0
-
-	buf[offset] = 0xEE
-	offset += 1
-
-	buf[offset] = 24 // 1(ID) + 22 + 1(CRC)
-	offset += 1
-
-	buf[offset] = 0x16
-	offset += 1
-
-	for i := 0; i < 16; i++ {
-		var val = channels[i]
-		shifted := val << bitsAvailable
-		bits |= shifted
-		bitsAvailable += CrossfireChBits
-		for bitsAvailable >= 8 {
-			buf[offset] = byte(bits)
-			offset += 1
-			bits >>= 8
-			bitsAvailable -= 8
-		}
-	}
+	frame := Channels{Addr: ModuleEndpoint, Values: *channels}
+	return frame.Marshal()
+}
 
-	buf[25] = crc.D5(buf[2:25])
-	//fmt.Printf("%d\n", channels)
-	//fmt.Printf("%x\n", buf)
-	return buf[:]
+// UnpackChannels is the inverse of PackChannels, it takes the full frame (including address, length and crc)
+func UnpackChannels(frame []byte) (channels [16]util.CRSFValue, ok bool) {
+	var decoded Channels
+	if err := decoded.Unmarshal(frame); err != nil {
+		return channels, false
+	}
+	return decoded.Values, true
 }
 
 func AdjustSendRate(rate int32, offset int32) time.Duration {
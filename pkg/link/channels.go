@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+)
+
+// ChannelModelResolution is the resolution the controller keeps channel values at,
+// lower resolution encodings drop the least significant bits
+const ChannelModelResolution = crsf.Resolution13Bit
+
+// ChannelEncoding selects how channels are sent to the TX module
+type ChannelEncoding struct {
+	// Subset sends the subset channels frame, otherwise the classic 16 channels, 11-bit frame is sent
+	Subset       bool
+	Resolution   crsf.ChannelResolution
+	StartChannel uint8
+	Count        uint8
+}
+
+func DefaultChannelEncoding() ChannelEncoding {
+	return ChannelEncoding{
+		Subset:       false,
+		Resolution:   crsf.Resolution11Bit,
+		StartChannel: 0,
+		Count:        16,
+	}
+}
+
+func (e ChannelEncoding) Validate() error {
+	if !e.Subset {
+		return nil
+	}
+
+	if !e.Resolution.IsValid() {
+		return errors.New(fmt.Sprintf("channel resolution must be between %d and %d bits, but got %d", crsf.Resolution10Bit, crsf.Resolution13Bit, e.Resolution))
+	}
+
+	if e.Count == 0 || int(e.Count) > e.Resolution.MaxSubsetChannels() {
+		return errors.New(fmt.Sprintf("at most %d channels fit into a %d-bit subset channels frame, but got %d", e.Resolution.MaxSubsetChannels(), e.Resolution, e.Count))
+	}
+
+	if int(e.StartChannel)+int(e.Count) > crsf.MaxChannels {
+		return errors.New(fmt.Sprintf("channels %d to %d are out of range, there are %d channels", e.StartChannel, int(e.StartChannel)+int(e.Count)-1, crsf.MaxChannels))
+	}
+
+	return nil
+}
+
+func (e ChannelEncoding) String() string {
+	if !e.Subset {
+		return "16 channels, 11-bit"
+	}
+	return fmt.Sprintf("channels %d to %d, %d-bit", e.StartChannel, int(e.StartChannel)+int(e.Count)-1, e.Resolution)
+}
+
+// SetChannelEncoding changes the channels frame written by the send loop
+func (c *Controller) SetChannelEncoding(encoding ChannelEncoding) error {
+	if err := encoding.Validate(); err != nil {
+		return err
+	}
+
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	c.channelEncoding = encoding
+	return nil
+}
+
+func (c *Controller) GetChannelEncoding() ChannelEncoding {
+	c.channelsMutex.RLock()
+	defer c.channelsMutex.RUnlock()
+	return c.channelEncoding
+}
+
+// UpdateChannelsHighRes sets all channels from values at ChannelModelResolution
+func (c *Controller) UpdateChannelsHighRes(channels [crsf.MaxChannels]util.CRSFValue) {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	c.currentChannels = &channels
+}
+
+// GetChannelsHighRes returns all channels at ChannelModelResolution
+func (c *Controller) GetChannelsHighRes() [crsf.MaxChannels]util.CRSFValue {
+	c.channelsMutex.RLock()
+	defer c.channelsMutex.RUnlock()
+	return *c.currentChannels
+}
+
+// SetChannel sets a single channel from a value at ChannelModelResolution
+func (c *Controller) SetChannel(channel int, value util.CRSFValue) error {
+	if channel < 0 || channel >= crsf.MaxChannels {
+		return errors.New(fmt.Sprintf("channel %d is out of range, there are %d channels", channel, crsf.MaxChannels))
+	}
+
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+
+	updated := *c.currentChannels
+	updated[channel] = min(value, ChannelModelResolution.MaxValue())
+	c.currentChannels = &updated
+	return nil
+}
+
+// SetChannelOverrides replaces channels with values at ChannelModelResolution until the overrides are cleared,
+// whatever the channels are set to in the meantime. Every call replaces the previous overrides.
+func (c *Controller) SetChannelOverrides(overrides map[int]util.CRSFValue) error {
+	for channel := range overrides {
+		if channel < 0 || channel >= crsf.MaxChannels {
+			return errors.New(fmt.Sprintf("channel %d is out of range, there are %d channels", channel, crsf.MaxChannels))
+		}
+	}
+
+	copied := make(map[int]util.CRSFValue, len(overrides))
+	for channel, value := range overrides {
+		copied[channel] = min(value, ChannelModelResolution.MaxValue())
+	}
+
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	c.channelOverrides = copied
+	return nil
+}
+
+// ClearChannelOverrides hands the channels back to their current values
+func (c *Controller) ClearChannelOverrides() {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	c.channelOverrides = nil
+}
+
+// createChannelsFrame encodes the current channels with the configured encoding
+func (c *Controller) createChannelsFrame() []byte {
+	armRefused := c.armRefused()
+
+	c.channelsMutex.RLock()
+	encoding := c.channelEncoding
+	current := *c.currentChannels
+	for channel, value := range c.channelOverrides {
+		current[channel] = value
+	}
+	c.channelsMutex.RUnlock()
+
+	if armRefused {
+		disarm(&current)
+	}
+
+	if !encoding.Subset {
+		var channels [16]util.CRSFValue
+		for i := range channels {
+			channels[i] = crsf.ScaleChannel(current[i], ChannelModelResolution, crsf.Resolution11Bit)
+		}
+		return crsf.PackChannels(&channels)
+	}
+
+	values := make([]util.CRSFValue, encoding.Count)
+	for i := range values {
+		values[i] = crsf.ScaleChannel(current[int(encoding.StartChannel)+i], ChannelModelResolution, encoding.Resolution)
+	}
+
+	frame := crsf.SubsetChannels{
+		Addr:         crsf.ModuleEndpoint,
+		StartChannel: encoding.StartChannel,
+		Resolution:   encoding.Resolution,
+		Values:       values,
+	}
+	return frame.Marshal()
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+	"time"
+)
+
+// DefaultCommandPollInterval is used for command fields that do not have a timeout
+const DefaultCommandPollInterval = 1 * time.Second
+
+// ConfirmFunc is called when a command asks for confirmation (the prompt is the field's Message),
+// returning false cancels the command
+type ConfirmFunc func(field settings.CommandFieldType) bool
+
+// RunCommand clicks a command field (e.g. "Bind" or "Enable WiFi"), and follows it until the device is done.
+// While the command executes, it is queried at the field's timeout. Confirmation prompts are passed to confirm,
+// a nil confirm cancels them. It returns the command's final message, or the last one it showed.
+func (c *Controller) RunCommand(ctx context.Context, deviceId crossfire.Endpoint, field settings.FieldType, confirm ConfirmFunc) (string, error) {
+	command, ok := field.(settings.CommandFieldType)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("field %s (%s) is not a command", field.Name(), field.Type()))
+	}
+
+	c.parametersMutex.Lock()
+	defer c.parametersMutex.Unlock()
+
+	fmt.Printf("(command) running %s\n", command.Name())
+
+	lastMessage := ""
+	cancelled := false
+	step := settings.StepClick
+	for {
+		var err error
+		if command, err = c.writeCommandStep(deviceId, command, step); err != nil {
+			return lastMessage, err
+		}
+
+		if command.Message() != "" {
+			lastMessage = command.Message()
+		}
+
+		fmt.Printf("(command) %s is %s: %s\n", command.Name(), command.Step(), command.Message())
+
+		switch command.Step() {
+		case settings.StepIdle:
+			if cancelled {
+				return lastMessage, errors.New(fmt.Sprintf("command %s was cancelled", command.Name()))
+			}
+			if command.Message() != "" {
+				return command.Message(), nil
+			}
+			return lastMessage, nil
+
+		case settings.StepAskConfirm:
+			if confirm != nil && confirm(command) {
+				step = settings.StepConfirmed
+			} else {
+				step = settings.StepCancel
+				cancelled = true
+			}
+			continue
+
+		case settings.StepCancel:
+			return lastMessage, errors.New(fmt.Sprintf("command %s was cancelled", command.Name()))
+		}
+
+		//still executing
+		interval := time.Duration(command.Timeout()) * 10 * time.Millisecond
+		if interval == 0 {
+			interval = DefaultCommandPollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			//do not leave the command waiting on the device
+			_, _ = c.writeCommandStep(deviceId, command, settings.StepCancel)
+			return lastMessage, ctx.Err()
+		case <-time.After(interval):
+		}
+		step = settings.StepQuery
+	}
+}
+
+// writeCommandStep writes the step, and reads the command back
+func (c *Controller) writeCommandStep(deviceId crossfire.Endpoint, command settings.CommandFieldType, step settings.CommandStep) (settings.CommandFieldType, error) {
+	fieldId := uint8(command.Id())
+	field, err := c.writeParameterValue(deviceId, fieldId, []uint8{uint8(step)})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, ok := field.(settings.CommandFieldType)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("field %d is no longer a command, it is %s", fieldId, field.Type()))
+	}
+	return updated, nil
+}
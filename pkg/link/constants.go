@@ -11,6 +11,10 @@ const (
 	PingDevices ChannelRequest = iota
 )
 
+type SpeedProposalRequest struct {
+	baudRate int32
+}
+
 type ReadDeviceFieldsRequest struct {
 	deviceId   uint8
 	fieldId    uint8
@@ -29,6 +33,36 @@ type WriteDeviceFieldRequestUint16 struct {
 	fieldValue uint16
 }
 
+// WriteDeviceFieldRequest carries a value encoded by settings.EncodeValue
+type WriteDeviceFieldRequest struct {
+	deviceId   uint8
+	fieldId    uint8
+	fieldValue []uint8
+}
+
+// MSPRequest carries the frames of an MSP request, they are sent in order
+type MSPRequest struct {
+	frames [][]byte
+}
+
+// MSPCancelRequest drops the frames of a timed out MSP request that were not sent yet
+type MSPCancelRequest struct{}
+
+// MAVLinkRequest carries the envelope frames of MAVLink bytes, they are sent in order
+type MAVLinkRequest struct {
+	frames [][]byte
+}
+
+// DisplayPortRequest carries a display port command for the flight controller
+type DisplayPortRequest struct {
+	frame []byte
+}
+
+// RelayRequest carries a frame of the handset, it goes to the module unchanged
+type RelayRequest struct {
+	frame []byte
+}
+
 type PortState int32
 
 const (
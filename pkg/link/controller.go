@@ -5,10 +5,14 @@
 package link
 
 import (
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
 	sc "github.com/kaack/elrs-joystick-control/pkg/serial"
 	"github.com/kaack/elrs-joystick-control/pkg/util"
 	"gopkg.in/tomb.v2"
 	"sync"
+	"time"
 )
 
 // Simple telemetry types
@@ -44,8 +48,16 @@ type AttitudeData struct {
 type Controller struct {
 	serialCtl *sc.Controller
 
-	currentChannels *[16]util.CRSFValue
-	channelsMutex   sync.RWMutex
+	// currentChannels holds the channel values at ChannelModelResolution
+	currentChannels *[crsf.MaxChannels]util.CRSFValue
+	channelEncoding ChannelEncoding
+	// channelOverrides replace channels at ChannelModelResolution, e.g. the sticks while navigating the CMS menu
+	channelOverrides map[int]util.CRSFValue
+	channelsMutex    sync.RWMutex
+
+	// phaseLockState is published by the send loop
+	phaseLockState PhaseLockState
+	phaseLockMutex sync.RWMutex
 
 	portState       PortState
 	supervisorState SupervisorState
@@ -65,14 +77,73 @@ type Controller struct {
 	GPSChan        chan GPSData
 	AttitudeChan   chan AttitudeData
 
+	// Sensor telemetry channels
+	RPMChan         chan RPMData
+	TemperatureChan chan TemperatureData
+	CellsChan       chan CellsData
+	AirspeedChan    chan AirspeedData
+	HeartbeatChan   chan HeartbeatData
+	GPSTimeChan     chan GPSTimeData
+	GPSExtendedChan chan GPSExtendedData
+
+	// ArduPilotChan receives the passthrough telemetry of ArduPilot, decoded into the crossfire AP* types
+	// (crossfire.APStatusText, crossfire.APStatus, ...), packets of unknown sensor ids as crossfire.PassthroughPacket
+	ArduPilotChan chan any
+
+	// UnhandledFrameChan receives the frames the controller does not handle itself, frames without a decoder
+	// (as telemetry.RawFrame) and frames of the decoders the application registered
+	UnhandledFrameChan chan telem.TelemType
+
+	// DisplayPortChan receives the screen of the CMS menu whenever the flight controller draws on it
+	DisplayPortChan chan DisplayPortScreen
+
+	// StatusChan receives the status frames of the TX module
+	StatusChan chan LinkStatus
+
+	// DeviceEventChan reports devices appearing on the link, changing, and going silent
+	DeviceEventChan chan DeviceEvent
+
 	sendChan chan any
 	recvChan chan any
+
+	recorder *capture.Recorder
+
+	speedChan    chan *speedNegotiation
+	speedAckChan chan bool
+	linkUpChan   chan any
+
+	parametersMutex    sync.Mutex
+	deviceInfoChan     chan DeviceInfo
+	parameterChunkChan chan parameterChunk
+
+	devices      map[crsf.Endpoint]*deviceEntry
+	devicesMutex sync.RWMutex
+
+	modelId             uint8
+	refuseArmOnMismatch bool
+	relayed             bool // the handset of a Relay selects the model and pings the devices
+	linkStatus          LinkStatus
+	modelMutex          sync.RWMutex
+
+	mspMutex sync.Mutex
+	mspSeq   uint8
+	mspChan  chan *crsf.MSP
+
+	mavlink *MAVLinkStream
+
+	displayPort      [][]byte
+	displayPortMutex sync.RWMutex
+	cmsKeyTimer      *time.Timer
+	cmsKeyMutex      sync.Mutex
+
+	//only used by the recv loop
+	passthrough crsf.PassthroughDecoder
 }
 
 func NewCtl(sc *sc.Controller) *Controller {
-	defaultChannels := &[16]util.CRSFValue{
-		992, 992, 992, 992, 992, 992, 992, 992,
-		992, 992, 992, 992, 992, 992, 992, 992,
+	defaultChannels := &[crsf.MaxChannels]util.CRSFValue{}
+	for i := range defaultChannels {
+		defaultChannels[i] = crsf.ScaleChannel(992, crsf.Resolution11Bit, ChannelModelResolution)
 	}
 
 	linkCtl := &Controller{
@@ -80,12 +151,35 @@ func NewCtl(sc *sc.Controller) *Controller {
 		supervisorState: SupervisorInactive,
 		serialCtl:       sc,
 		currentChannels: defaultChannels,
+		channelEncoding: DefaultChannelEncoding(),
 		// Create buffered channels for telemetry
 		LinkStatsChan:   make(chan LinkStats, 10),
 		BatteryChan:     make(chan BatteryData, 10),
 		GPSChan:         make(chan GPSData, 10),
 		AttitudeChan:    make(chan AttitudeData, 10),
+		RPMChan:         make(chan RPMData, 10),
+		TemperatureChan: make(chan TemperatureData, 10),
+		CellsChan:       make(chan CellsData, 10),
+		AirspeedChan:    make(chan AirspeedData, 10),
+		HeartbeatChan:   make(chan HeartbeatData, 10),
+		GPSTimeChan:     make(chan GPSTimeData, 10),
+		GPSExtendedChan: make(chan GPSExtendedData, 10),
+		ArduPilotChan:   make(chan any, 32),
+		DisplayPortChan: make(chan DisplayPortScreen, 1),
+
+		UnhandledFrameChan: make(chan telem.TelemType, 32),
+		StatusChan:      make(chan LinkStatus, 10),
+		DeviceEventChan: make(chan DeviceEvent, 10),
+		speedAckChan:    make(chan bool, 1),
+		linkUpChan:      make(chan any, 1),
+
+		deviceInfoChan:     make(chan DeviceInfo, 8),
+		parameterChunkChan: make(chan parameterChunk, 16),
+
+		devices: map[crsf.Endpoint]*deviceEntry{},
+		mspChan: make(chan *crsf.MSP, 32),
 	}
+	linkCtl.mavlink = newMAVLinkStream(linkCtl)
 
 	return linkCtl
 }
@@ -100,18 +194,48 @@ func (c *Controller) Quit() {
 	close(c.BatteryChan)
 	close(c.GPSChan)
 	close(c.AttitudeChan)
+	close(c.RPMChan)
+	close(c.TemperatureChan)
+	close(c.CellsChan)
+	close(c.AirspeedChan)
+	close(c.HeartbeatChan)
+	close(c.GPSTimeChan)
+	close(c.GPSExtendedChan)
+	close(c.ArduPilotChan)
+	close(c.DisplayPortChan)
+	close(c.UnhandledFrameChan)
+	close(c.StatusChan)
+	close(c.DeviceEventChan)
+	_ = c.mavlink.Close()
 }
 
+// UpdateChannels sets the first 16 channels from 11-bit values
 func (c *Controller) UpdateChannels(channels [16]util.CRSFValue) {
 	c.channelsMutex.Lock()
 	defer c.channelsMutex.Unlock()
-	c.currentChannels = &channels
+
+	updated := *c.currentChannels
+	for i, value := range channels {
+		updated[i] = crsf.ScaleChannel(value, crsf.Resolution11Bit, ChannelModelResolution)
+	}
+	c.currentChannels = &updated
 }
 
+// GetChannels returns the first 16 channels as 11-bit values
 func (c *Controller) GetChannels() [16]util.CRSFValue {
 	c.channelsMutex.RLock()
 	defer c.channelsMutex.RUnlock()
-	return *c.currentChannels
+
+	var channels [16]util.CRSFValue
+	for i := range channels {
+		channels[i] = crsf.ScaleChannel(c.currentChannels[i], ChannelModelResolution, crsf.Resolution11Bit)
+	}
+	return channels
+}
+
+// SetRecorder records all port traffic of the link sessions started afterwards, nil disables recording
+func (c *Controller) SetRecorder(recorder *capture.Recorder) {
+	c.recorder = recorder
 }
 
 func (c *Controller) IsActive() bool {
@@ -160,3 +284,10 @@ func (c *Controller) sendAttitude(attitude AttitudeData) {
 	default:
 	}
 }
+
+func (c *Controller) sendUnhandledFrame(frame telem.TelemType) {
+	select {
+	case c.UnhandledFrameChan <- frame:
+	default:
+	}
+}
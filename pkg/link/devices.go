@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// DevicePingInterval how often the devices on the link are pinged while it is active
+const DevicePingInterval = 5 * time.Second
+
+// DeviceSilentTimeout a device that has not answered a ping for this long is dropped from the registry
+const DeviceSilentTimeout = 3 * DevicePingInterval
+
+type DeviceEventType int32
+
+const (
+	DeviceAppeared DeviceEventType = iota
+	DeviceChanged  DeviceEventType = iota
+	DeviceSilent   DeviceEventType = iota
+)
+
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceAppeared:
+		return "appeared"
+	case DeviceChanged:
+		return "changed"
+	case DeviceSilent:
+		return "silent"
+	default:
+		return fmt.Sprintf("%d", int(t))
+	}
+}
+
+// DeviceEvent Device is the device info as last received (for DeviceSilent, the last info before it went silent)
+type DeviceEvent struct {
+	Type   DeviceEventType
+	Device DeviceInfo
+}
+
+type deviceEntry struct {
+	info     DeviceInfo
+	lastSeen time.Time
+}
+
+// Devices returns every device that answered a ping and has not gone silent, ordered by address
+func (c *Controller) Devices() []DeviceInfo {
+	c.devicesMutex.RLock()
+	defer c.devicesMutex.RUnlock()
+
+	devices := make([]DeviceInfo, 0, len(c.devices))
+	for _, entry := range c.devices {
+		devices = append(devices, entry.info)
+	}
+	slices.SortFunc(devices, func(a, b DeviceInfo) bool {
+		return a.Id < b.Id
+	})
+	return devices
+}
+
+// Device returns the info of the device at the given address, if it is known
+func (c *Controller) Device(deviceId crossfire.Endpoint) (DeviceInfo, bool) {
+	c.devicesMutex.RLock()
+	defer c.devicesMutex.RUnlock()
+
+	if entry, ok := c.devices[deviceId]; ok {
+		return entry.info, true
+	}
+	return DeviceInfo{}, false
+}
+
+// updateDevice records a ping answer
+func (c *Controller) updateDevice(info DeviceInfo) {
+	c.devicesMutex.Lock()
+	defer c.devicesMutex.Unlock()
+
+	entry, ok := c.devices[info.Id]
+	if !ok {
+		c.devices[info.Id] = &deviceEntry{info: info, lastSeen: time.Now()}
+		fmt.Printf("(devices) %s appeared at %x\n", info.Name, uint8(info.Id))
+		c.sendDeviceEvent(DeviceEvent{Type: DeviceAppeared, Device: info})
+		return
+	}
+
+	entry.lastSeen = time.Now()
+	if entry.info != info {
+		entry.info = info
+		fmt.Printf("(devices) %s at %x changed\n", info.Name, uint8(info.Id))
+		c.sendDeviceEvent(DeviceEvent{Type: DeviceChanged, Device: info})
+	}
+}
+
+// touchDevice keeps a known device from going silent, e.g. when it sends a heartbeat
+func (c *Controller) touchDevice(deviceId crossfire.Endpoint) {
+	c.devicesMutex.Lock()
+	defer c.devicesMutex.Unlock()
+
+	if entry, ok := c.devices[deviceId]; ok {
+		entry.lastSeen = time.Now()
+	}
+}
+
+// expireDevices drops the devices that have not answered for DeviceSilentTimeout
+func (c *Controller) expireDevices() {
+	c.devicesMutex.Lock()
+	defer c.devicesMutex.Unlock()
+
+	for id, entry := range c.devices {
+		if time.Since(entry.lastSeen) > DeviceSilentTimeout {
+			delete(c.devices, id)
+			fmt.Printf("(devices) %s at %x went silent\n", entry.info.Name, uint8(id))
+			c.sendDeviceEvent(DeviceEvent{Type: DeviceSilent, Device: entry.info})
+		}
+	}
+}
+
+func (c *Controller) sendDeviceEvent(event DeviceEvent) {
+	select {
+	case c.DeviceEventChan <- event:
+	default:
+	}
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"github.com/kaack/elrs-joystick-control/pkg/serial"
+	"gopkg.in/tomb.v2"
+	"io"
+	"time"
+)
+
+const DiscoveryProbeTimeout = 500 * time.Millisecond
+const discoveryPingInterval = 100 * time.Millisecond
+
+type DiscoveredModule struct {
+	Port            string `json:"port"`
+	Product         string `json:"product"`
+	BaudRate        int32  `json:"baudRate"`
+	Name            string `json:"name"`
+	SerialNumber    uint32 `json:"serialNumber"`
+	HardwareVersion string `json:"hardwareVersion"`
+	SoftwareVersion string `json:"softwareVersion"`
+}
+
+// DiscoverModules probes every serial port at every supported baud rate, and returns the TX modules that answered a ping
+func (c *Controller) DiscoverModules(probeTimeout time.Duration) ([]DiscoveredModule, error) {
+	ports, err := c.serialCtl.GetSerialPorts()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []DiscoveredModule
+	for _, port := range ports {
+		for _, baudRate := range crossfire.GetBaudRates() {
+			fmt.Printf("(discovery) probing port %s at %d baud\n", port.Name, baudRate)
+			module, err := ProbeModule(&serial.Port{Name: port.Name, BaudRate: baudRate}, probeTimeout)
+			if err != nil {
+				fmt.Printf("(discovery) nothing found on port %s at %d baud. %s\n", port.Name, baudRate, err.Error())
+				continue
+			}
+
+			module.Product = port.Product
+			fmt.Printf("(discovery) found %s on port %s at %d baud\n", module.Name, port.Name, baudRate)
+			res = append(res, *module)
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// ProbeModule opens the port, pings the devices on it, and waits for the TX module's device info
+func ProbeModule(port serial.Transport, probeTimeout time.Duration) (*DiscoveredModule, error) {
+	if err := port.SetReadTimeout(crossfire.GetRefreshRate(port.PortBaudRate()) * 4); err != nil {
+		return nil, err
+	}
+
+	if err := port.Open(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		action("closing probed port", port.Close())
+	}()
+
+	probeTomb := &tomb.Tomb{}
+	probeTomb.Go(func() error {
+		ticker := time.NewTicker(discoveryPingInterval)
+		defer ticker.Stop()
+
+		timeout := time.After(probeTimeout)
+		for {
+			if _, err := port.Write(crossfire.CreatePingDevicesFrame()); err != nil {
+				return err
+			}
+
+			select {
+			case <-probeTomb.Dying():
+				return nil
+			case <-timeout:
+				return errors.New("timed out waiting for device info")
+			case <-ticker.C:
+			}
+		}
+	})
+
+	reader := telem.NewReader(port)
+	for {
+		tPacket, err := reader.Next(probeTomb)
+		if err != nil {
+			if _, ok := err.(*telem.InterruptedError); ok {
+				if err = probeTomb.Wait(); err == nil {
+					err = errors.New("probe was interrupted")
+				}
+				return nil, err
+			}
+			if errors.Is(err, io.EOF) {
+				probeTomb.Kill(nil)
+				action("stopping probe pings", probeTomb.Wait())
+				return nil, err
+			}
+			//garbage is expected at the wrong baud rate, keep reading until the timeout
+			continue
+		}
+
+		if info, ok := tPacket.(telem.TelemDeviceInfoExtType); ok && info.Src() == crossfire.ModuleEndpoint {
+			probeTomb.Kill(nil)
+			action("stopping probe pings", probeTomb.Wait())
+
+			return &DiscoveredModule{
+				Port:            port.PortName(),
+				BaudRate:        port.PortBaudRate(),
+				Name:            info.DeviceName(),
+				SerialNumber:    info.SerialNumber(),
+				HardwareVersion: info.HardwareVersion(),
+				SoftwareVersion: info.SoftwareVersion(),
+			}, nil
+		}
+	}
+}
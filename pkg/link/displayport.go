@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"io"
+	"strings"
+	"time"
+)
+
+// the sticks in the default AETR channel order, like ArmChannel assumes the ELRS channel order
+const (
+	RollChannel     = 0
+	PitchChannel    = 1
+	ThrottleChannel = 2
+	YawChannel      = 3
+)
+
+// 11-bit stick positions (1000us, 1500us, 2000us)
+const (
+	StickLow  util.CRSFValue = 172
+	StickMid  util.CRSFValue = 992
+	StickHigh util.CRSFValue = 1811
+)
+
+// CMSKeyHold how long the sticks are held for a key press. Betaflight repeats keys that are held,
+// and needs the sticks back in the center between presses.
+const CMSKeyHold = 200 * time.Millisecond
+
+// CMSKey a stick command of the Betaflight CMS menu
+type CMSKey int32
+
+const (
+	CMSKeyMenu     CMSKey = iota // opens the menu, throttle centered, yaw left, pitch up (disarmed only)
+	CMSKeyUp       CMSKey = iota // pitch up
+	CMSKeyDown     CMSKey = iota // pitch down
+	CMSKeyLeft     CMSKey = iota // roll left, back or decrease
+	CMSKeyRight    CMSKey = iota // roll right, enter or increase
+	CMSKeyEsc      CMSKey = iota // yaw left
+	CMSKeySaveMenu CMSKey = iota // yaw right
+)
+
+func (k CMSKey) String() string {
+	switch k {
+	case CMSKeyMenu:
+		return "menu"
+	case CMSKeyUp:
+		return "up"
+	case CMSKeyDown:
+		return "down"
+	case CMSKeyLeft:
+		return "left"
+	case CMSKeyRight:
+		return "right"
+	case CMSKeyEsc:
+		return "esc"
+	case CMSKeySaveMenu:
+		return "save-menu"
+	default:
+		return fmt.Sprintf("%d", int(k))
+	}
+}
+
+// DisplayPortScreen is a copy of the virtual screen the flight controller draws its CMS menu on
+type DisplayPortScreen struct {
+	Lines []string
+}
+
+func (s DisplayPortScreen) String() string {
+	return strings.Join(s.Lines, "\n")
+}
+
+// Render draws the screen in a frame at the top left corner of an ANSI terminal
+func (s DisplayPortScreen) Render(w io.Writer) error {
+	width := 0
+	for _, line := range s.Lines {
+		width = max(width, len(line))
+	}
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	b.WriteString("+" + strings.Repeat("-", width) + "+\r\n")
+	for _, line := range s.Lines {
+		b.WriteString("|" + line + strings.Repeat(" ", width-len(line)) + "|\r\n")
+	}
+	b.WriteString("+" + strings.Repeat("-", width) + "+\r\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// OpenCMS asks the flight controller to draw its CMS menu on a screen of the given size,
+// the size is limited to what Betaflight draws over CRSF
+func (c *Controller) OpenCMS(rows int, cols int) error {
+	rows = max(1, min(rows, crsf.DisplayPortMaxRows))
+	cols = max(1, min(cols, crsf.DisplayPortMaxCols))
+
+	c.displayPortMutex.Lock()
+	c.displayPort = make([][]byte, rows)
+	for i := range c.displayPort {
+		c.displayPort[i] = []byte(strings.Repeat(" ", cols))
+	}
+	c.displayPortMutex.Unlock()
+
+	return c.sendDisplayPortCommand(&crsf.DisplayPort{SubCommand: crsf.DisplayPortOpen, Rows: uint8(rows), Cols: uint8(cols)})
+}
+
+// CloseCMS asks the flight controller to leave the CMS menu
+func (c *Controller) CloseCMS() error {
+	return c.sendDisplayPortCommand(&crsf.DisplayPort{SubCommand: crsf.DisplayPortClose})
+}
+
+// PollCMS asks the flight controller to draw the CMS menu again
+func (c *Controller) PollCMS() error {
+	return c.sendDisplayPortCommand(&crsf.DisplayPort{SubCommand: crsf.DisplayPortPoll})
+}
+
+// DisplayPortScreen returns a copy of the screen as drawn so far
+func (c *Controller) DisplayPortScreen() DisplayPortScreen {
+	c.displayPortMutex.RLock()
+	defer c.displayPortMutex.RUnlock()
+
+	lines := make([]string, len(c.displayPort))
+	for i, row := range c.displayPort {
+		lines[i] = string(row)
+	}
+	return DisplayPortScreen{Lines: lines}
+}
+
+// PressCMSKey moves the sticks for a CMS key, overriding the channels for CMSKeyHold. Sticks that are
+// not part of the key are centered, the throttle is left alone unless the key opens the menu.
+func (c *Controller) PressCMSKey(key CMSKey) error {
+	sticks := map[int]util.CRSFValue{
+		RollChannel:  StickMid,
+		PitchChannel: StickMid,
+		YawChannel:   StickMid,
+	}
+
+	switch key {
+	case CMSKeyMenu:
+		sticks[ThrottleChannel] = StickMid
+		sticks[YawChannel] = StickLow
+		sticks[PitchChannel] = StickHigh
+	case CMSKeyUp:
+		sticks[PitchChannel] = StickHigh
+	case CMSKeyDown:
+		sticks[PitchChannel] = StickLow
+	case CMSKeyLeft:
+		sticks[RollChannel] = StickLow
+	case CMSKeyRight:
+		sticks[RollChannel] = StickHigh
+	case CMSKeyEsc:
+		sticks[YawChannel] = StickLow
+	case CMSKeySaveMenu:
+		sticks[YawChannel] = StickHigh
+	default:
+		return errors.New(fmt.Sprintf("unknown cms key %d", int(key)))
+	}
+
+	for channel, value := range sticks {
+		sticks[channel] = crsf.ScaleChannel(value, crsf.Resolution11Bit, ChannelModelResolution)
+	}
+
+	c.cmsKeyMutex.Lock()
+	defer c.cmsKeyMutex.Unlock()
+
+	if c.cmsKeyTimer != nil {
+		c.cmsKeyTimer.Stop()
+	}
+	if err := c.SetChannelOverrides(sticks); err != nil {
+		return err
+	}
+	c.cmsKeyTimer = time.AfterFunc(CMSKeyHold, c.ClearChannelOverrides)
+	return nil
+}
+
+func (c *Controller) sendDisplayPortCommand(frame *crsf.DisplayPort) error {
+	frame.Addr = crsf.ModuleEndpoint
+	frame.Dst = crsf.FlightControllerEndpoint
+	frame.Src = crsf.HandsetEndpoint
+	return c.request(DisplayPortRequest{frame: frame.Marshal()})
+}
+
+// receiveDisplayPort draws the updates of the flight controller on the screen, and hands a copy to DisplayPortChan
+func (c *Controller) receiveDisplayPort(frame *crsf.DisplayPort) {
+	c.displayPortMutex.Lock()
+	switch frame.SubCommand {
+	case crsf.DisplayPortClear:
+		for _, row := range c.displayPort {
+			for i := range row {
+				row[i] = ' '
+			}
+		}
+	case crsf.DisplayPortUpdate:
+		if int(frame.Row) >= crsf.DisplayPortMaxRows {
+			c.displayPortMutex.Unlock()
+			fmt.Printf("(displayport) row %d is off the screen\n", frame.Row)
+			return
+		}
+		//the screen grows to whatever the flight controller draws, in case it was never opened from here
+		for len(c.displayPort) <= int(frame.Row) {
+			c.displayPort = append(c.displayPort, nil)
+		}
+		row := c.displayPort[frame.Row]
+		if len(row) < len(frame.Text) {
+			row = append(row, []byte(strings.Repeat(" ", len(frame.Text)-len(row)))...)
+		}
+		for i := range row {
+			//anything outside of printable ascii is a glyph of the osd font
+			if i < len(frame.Text) && frame.Text[i] >= 0x20 && frame.Text[i] < 0x7F {
+				row[i] = frame.Text[i]
+			} else {
+				row[i] = ' '
+			}
+		}
+		c.displayPort[frame.Row] = row
+	default:
+		c.displayPortMutex.Unlock()
+		return
+	}
+	c.displayPortMutex.Unlock()
+
+	//only the latest screen is of interest
+	drain(c.DisplayPortChan)
+	select {
+	case c.DisplayPortChan <- c.DisplayPortScreen():
+	default:
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+)
+
+// ParameterReadError the device did not answer a parameter request, even after retrying
+type ParameterReadError struct {
+	DeviceId crossfire.Endpoint
+	FieldId  uint8
+	Message  string
+}
+
+func (e *ParameterReadError) Error() string {
+	return e.Message
+}
+
+// ParameterWriteError the device did not take the written value, Field is the field as read back after the write
+type ParameterWriteError struct {
+	DeviceId crossfire.Endpoint
+	Field    settings.FieldType
+	Written  []byte
+	Actual   []byte
+}
+
+func (e *ParameterWriteError) Error() string {
+	return fmt.Sprintf("device %x did not accept the value of field %d (%s). wrote %x, but it is %x",
+		uint8(e.DeviceId), e.Field.Id(), e.Field.Name(), e.Written, e.Actual)
+}
+
+// MSPError the flight controller answered an MSP request with an error, e.g. because it does not know the command
+type MSPError struct {
+	Command uint16
+	Payload []byte
+}
+
+func (e *MSPError) Error() string {
+	return fmt.Sprintf("flight controller rejected msp command %d", e.Command)
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"io"
+	"sync"
+)
+
+// MAVLinkStream is the MAVLink byte stream carried in envelope frames, when ELRS runs the link in MAVLink mode.
+// Reads block until the aircraft sends something, writes are sent with the next channel frames.
+type MAVLinkStream struct {
+	ctl *Controller
+
+	recvChan  chan []byte
+	closed    chan any
+	closeOnce sync.Once
+
+	readMutex sync.Mutex
+	pending   []byte
+
+	//only used by the recv loop
+	assembler crsf.MavlinkAssembler
+}
+
+func newMAVLinkStream(ctl *Controller) *MAVLinkStream {
+	return &MAVLinkStream{
+		ctl:      ctl,
+		recvChan: make(chan []byte, 64),
+		closed:   make(chan any),
+	}
+}
+
+// MAVLink returns the MAVLink stream of the link
+func (c *Controller) MAVLink() *MAVLinkStream {
+	return c.mavlink
+}
+
+func (s *MAVLinkStream) Read(p []byte) (int, error) {
+	return s.read(p, nil)
+}
+
+// read works like Read, and returns io.EOF early if done is closed
+func (s *MAVLinkStream) read(p []byte, done <-chan struct{}) (int, error) {
+	s.readMutex.Lock()
+	defer s.readMutex.Unlock()
+
+	if len(s.pending) == 0 {
+		select {
+		case data := <-s.recvChan:
+			s.pending = data
+		case <-s.closed:
+			return 0, io.EOF
+		case <-done:
+			return 0, io.EOF
+		}
+	}
+
+	count := copy(p, s.pending)
+	s.pending = s.pending[count:]
+	return count, nil
+}
+
+func (s *MAVLinkStream) Write(p []byte) (int, error) {
+	envelopes := crsf.NewMavlinkEnvelopes(crsf.ModuleEndpoint, p)
+	frames := make([][]byte, len(envelopes))
+	for i, envelope := range envelopes {
+		frames[i] = envelope.Marshal()
+	}
+
+	if err := s.ctl.request(MAVLinkRequest{frames: frames}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close ends pending and future reads
+func (s *MAVLinkStream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+	return nil
+}
+
+func (s *MAVLinkStream) receive(envelope *crsf.MavlinkEnvelope) {
+	data, err := s.assembler.Push(envelope)
+	if err != nil {
+		fmt.Printf("(mavlink) %s\n", err.Error())
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	select {
+	case s.recvChan <- data:
+	default:
+		fmt.Printf("(mavlink) nobody is reading the stream, dropping %d bytes\n", len(data))
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/tomb.v2"
+	"io"
+	"net"
+	"sync"
+)
+
+// DefaultGCSAddress ground control stations (QGroundControl, Mission Planner) listen for MAVLink on UDP port 14550
+const DefaultGCSAddress = "127.0.0.1:14550"
+
+// MAVLinkUDPBridge connects the MAVLink stream of the link to a ground control station over UDP.
+// Datagrams received on Address are written to the stream. The stream is sent to Remote,
+// or if Remote is empty, to whichever peer sent the most recent datagram.
+type MAVLinkUDPBridge struct {
+	Stream  *MAVLinkStream
+	Address string
+	Remote  string
+
+	peerMutex sync.Mutex
+	peerAddr  net.Addr
+
+	tomb *tomb.Tomb
+}
+
+func (b *MAVLinkUDPBridge) Start() error {
+	if b.tomb != nil && b.tomb.Alive() {
+		return errors.New("mavlink bridge is already active")
+	}
+
+	conn, err := net.ListenPacket("udp", b.Address)
+	if err != nil {
+		return err
+	}
+
+	if b.Remote != "" {
+		if b.peerAddr, err = net.ResolveUDPAddr("udp", b.Remote); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+
+	fmt.Printf("(mavlink-bridge) starting, listen: udp://%s, remote: %s\n", conn.LocalAddr(), b.Remote)
+
+	b.tomb = &tomb.Tomb{}
+	b.tomb.Go(func() error {
+		<-b.tomb.Dying()
+		return conn.Close()
+	})
+	b.tomb.Go(func() error {
+		return b.datagramLoop(conn)
+	})
+	b.tomb.Go(func() error {
+		return b.streamLoop(conn)
+	})
+
+	return nil
+}
+
+func (b *MAVLinkUDPBridge) Stop() error {
+	if b.tomb == nil || !b.tomb.Alive() {
+		return nil
+	}
+
+	b.tomb.Kill(nil)
+	return b.tomb.Wait()
+}
+
+// datagramLoop writes the datagrams of the ground control station to the stream
+func (b *MAVLinkUDPBridge) datagramLoop(conn net.PacketConn) error {
+	buf := make([]byte, 2048)
+
+	for {
+		count, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !b.tomb.Alive() {
+				return nil
+			}
+			return err
+		}
+
+		if b.Remote == "" {
+			b.peerMutex.Lock()
+			if b.peerAddr == nil || b.peerAddr.String() != addr.String() {
+				fmt.Printf("(mavlink-bridge) peer %s connected\n", addr)
+			}
+			b.peerAddr = addr
+			b.peerMutex.Unlock()
+		}
+
+		if _, err = b.Stream.Write(buf[:count]); err != nil {
+			fmt.Printf("(mavlink-bridge) dropping %d bytes from %s. %s\n", count, addr, err.Error())
+		}
+	}
+}
+
+// streamLoop sends what the aircraft sends to the ground control station
+func (b *MAVLinkUDPBridge) streamLoop(conn net.PacketConn) error {
+	buf := make([]byte, 2048)
+
+	for {
+		count, err := b.Stream.read(buf, b.tomb.Dying())
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		b.peerMutex.Lock()
+		peer := b.peerAddr
+		b.peerMutex.Unlock()
+
+		if peer == nil {
+			//no ground control station yet
+			continue
+		}
+
+		if _, err = conn.WriteTo(buf[:count], peer); err != nil && b.tomb.Alive() {
+			fmt.Printf("(mavlink-bridge) could not send %d bytes to %s. %s\n", count, peer, err.Error())
+		}
+	}
+}
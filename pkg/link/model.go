@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"golang.org/x/exp/slices"
+)
+
+// MaxModelId the highest model id ELRS model match can tell apart
+const MaxModelId = 63
+
+// ArmChannel ELRS arms on AUX1 (channel 5)
+const ArmChannel = 4
+
+// DisarmedValue the 11-bit value the arm channel is held at while arming is refused
+const DisarmedValue util.CRSFValue = 172
+
+// LinkStatus is the content of the status frames the TX module sends
+type LinkStatus struct {
+	Connected     bool
+	ModelMismatch bool
+	Armed         bool
+	BadPackets    uint32
+	GoodPackets   uint32
+	Message       string
+}
+
+// SetModelId changes the model id sent to the TX module. With model match enabled,
+// the receiver only accepts the link if it was bound with the same model id.
+func (c *Controller) SetModelId(modelId uint8) error {
+	if modelId > MaxModelId {
+		return errors.New(fmt.Sprintf("model id %d is out of range, at most %d", modelId, MaxModelId))
+	}
+
+	c.modelMutex.Lock()
+	c.modelId = modelId
+	c.modelMutex.Unlock()
+
+	//take effect now, rather than with the next periodic model id frame
+	if c.IsActive() {
+		c.SendModelID()
+	}
+	return nil
+}
+
+// setRelayed stops the model id and ping frames of the controller while a handset is relayed
+func (c *Controller) setRelayed(relayed bool) {
+	c.modelMutex.Lock()
+	defer c.modelMutex.Unlock()
+	c.relayed = relayed
+}
+
+func (c *Controller) isRelayed() bool {
+	c.modelMutex.RLock()
+	defer c.modelMutex.RUnlock()
+	return c.relayed
+}
+
+// trackModelId takes the model id the relayed handset selected, its frame goes to the module unchanged
+func (c *Controller) trackModelId(modelId uint8) {
+	c.modelMutex.Lock()
+	defer c.modelMutex.Unlock()
+	if c.modelId != modelId {
+		fmt.Printf("(relay) handset selected model %d\n", modelId)
+	}
+	c.modelId = modelId
+}
+
+func (c *Controller) GetModelId() uint8 {
+	c.modelMutex.RLock()
+	defer c.modelMutex.RUnlock()
+	return c.modelId
+}
+
+// SetRefuseArmOnModelMismatch holds the arm channel at DisarmedValue while the receiver reports a model mismatch
+func (c *Controller) SetRefuseArmOnModelMismatch(enabled bool) {
+	c.modelMutex.Lock()
+	defer c.modelMutex.Unlock()
+	c.refuseArmOnMismatch = enabled
+}
+
+// ModelMismatch tells whether the last status frame reported that the receiver belongs to another model
+func (c *Controller) ModelMismatch() bool {
+	c.modelMutex.RLock()
+	defer c.modelMutex.RUnlock()
+	return c.linkStatus.ModelMismatch
+}
+
+// GetLinkStatus returns the content of the last status frame
+func (c *Controller) GetLinkStatus() LinkStatus {
+	c.modelMutex.RLock()
+	defer c.modelMutex.RUnlock()
+	return c.linkStatus
+}
+
+func (c *Controller) armRefused() bool {
+	c.modelMutex.RLock()
+	defer c.modelMutex.RUnlock()
+	return c.refuseArmOnMismatch && c.linkStatus.ModelMismatch
+}
+
+func (c *Controller) updateLinkStatus(frame telem.TelemStatusExtType) {
+	flags := frame.Flags()
+	status := LinkStatus{
+		Connected: slices.Contains(flags, telem.StatusConnected),
+		//ELRS sets the model match flag while the models do not match
+		ModelMismatch: slices.Contains(flags, telem.StatusModelMatch),
+		Armed:         slices.Contains(flags, telem.StatusIsArmed),
+		BadPackets:    frame.BadPackets(),
+		GoodPackets:   frame.GoodPackets(),
+		Message:       frame.Message(),
+	}
+
+	c.modelMutex.Lock()
+	previous := c.linkStatus
+	c.linkStatus = status
+	refuseArm := c.refuseArmOnMismatch
+	c.modelMutex.Unlock()
+
+	if status.ModelMismatch && !previous.ModelMismatch {
+		if refuseArm {
+			fmt.Printf("(link) receiver reports a model mismatch for model id %d, refusing to arm\n", c.GetModelId())
+		} else {
+			fmt.Printf("(link) receiver reports a model mismatch for model id %d\n", c.GetModelId())
+		}
+	} else if !status.ModelMismatch && previous.ModelMismatch {
+		fmt.Printf("(link) receiver model matches model id %d\n", c.GetModelId())
+	}
+
+	c.sendStatus(status)
+}
+
+// disarm holds the arm channel low, channels are at ChannelModelResolution
+func disarm(channels *[crsf.MaxChannels]util.CRSFValue) {
+	channels[ArmChannel] = crsf.ScaleChannel(DisarmedValue, crsf.Resolution11Bit, ChannelModelResolution)
+}
+
+func (c *Controller) sendStatus(status LinkStatus) {
+	select {
+	case c.StatusChan <- status:
+	default:
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+// MSPRequestTimeout how long MSP waits for the answer, unless the context has an earlier deadline
+const MSPRequestTimeout = 2 * time.Second
+
+// MSP sends an MSP request to the flight controller, tunnelled through the link, and returns the payload of its answer.
+// MSPv2 is used for commands or payloads that do not fit into MSPv1.
+func (c *Controller) MSP(ctx context.Context, command uint16, payload []byte) ([]byte, error) {
+	c.mspMutex.Lock()
+	defer c.mspMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, MSPRequestTimeout)
+	defer cancel()
+
+	request := crsf.MSPPacket{
+		Version: crsf.MSPVersionFor(command, len(payload)),
+		Command: command,
+		Payload: payload,
+	}
+
+	frames, nextSeq, err := request.Fragment(crsf.MspRequestFrame, crsf.ModuleEndpoint,
+		crsf.FlightControllerEndpoint, crsf.HandsetEndpoint, c.mspSeq, crsf.MSPRequestChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	c.mspSeq = nextSeq
+
+	encoded := make([][]byte, len(frames))
+	for i, frame := range frames {
+		encoded[i] = frame.Marshal()
+	}
+
+	//discard answers to earlier (timed out) requests
+	drain(c.mspChan)
+
+	if err = c.request(MSPRequest{frames: encoded}); err != nil {
+		return nil, err
+	}
+
+	var assembler crsf.MSPAssembler
+	for {
+		select {
+		case <-ctx.Done():
+			//frames of the request still queued would reach the flight controller after we gave up on it
+			if err := c.request(MSPCancelRequest{}); err != nil {
+				fmt.Printf("(msp) could not cancel msp command %d. %s\n", command, err.Error())
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, errors.New(fmt.Sprintf("flight controller did not answer msp command %d", command))
+			}
+			return nil, ctx.Err()
+		case frame := <-c.mspChan:
+			response, err := assembler.Push(frame)
+			if err != nil {
+				fmt.Printf("(msp) %s\n", err.Error())
+				continue
+			}
+			if response == nil {
+				continue
+			}
+			if response.Command != command {
+				fmt.Printf("(msp) ignoring answer to msp command %d\n", response.Command)
+				continue
+			}
+			if response.Error {
+				return nil, &MSPError{Command: command, Payload: response.Payload}
+			}
+			return response.Payload, nil
+		}
+	}
+}
+
+func (c *Controller) sendMSPFrame(frame *crsf.MSP) {
+	if frame.Src != crsf.FlightControllerEndpoint {
+		return
+	}
+
+	select {
+	case c.mspChan <- frame:
+	default:
+	}
+}
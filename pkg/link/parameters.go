@@ -0,0 +1,283 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"time"
+)
+
+// ParameterReadTimeout how long to wait for a device to answer a parameter chunk (or ping) request
+const ParameterReadTimeout = 500 * time.Millisecond
+
+// ParameterReadRetries how many times a request is repeated before giving up
+const ParameterReadRetries = 3
+
+// MaxParameterChunks guards against devices that never stop sending chunks
+const MaxParameterChunks = 32
+
+type DeviceInfo struct {
+	Id               crossfire.Endpoint `json:"id"`
+	Name             string             `json:"name"`
+	SerialNumber     uint32             `json:"serialNumber"`
+	HardwareVersion  string             `json:"hardwareVersion"`
+	SoftwareVersion  string             `json:"softwareVersion"`
+	FieldCount       uint8              `json:"fieldCount"`
+	ParameterVersion uint8              `json:"parameterVersion"`
+}
+
+type parameterChunk struct {
+	deviceId        crossfire.Endpoint
+	fieldId         uint8
+	chunksRemaining uint8
+	data            []byte
+}
+
+// ReadDeviceInfo pings the devices, and waits for the answer of the given device
+func (c *Controller) ReadDeviceInfo(deviceId crossfire.Endpoint) (*DeviceInfo, error) {
+	c.parametersMutex.Lock()
+	defer c.parametersMutex.Unlock()
+
+	return c.readDeviceInfo(deviceId)
+}
+
+// ReadParameter reads all chunks of a single field, and parses it
+func (c *Controller) ReadParameter(deviceId crossfire.Endpoint, fieldId uint8) (settings.FieldType, error) {
+	c.parametersMutex.Lock()
+	defer c.parametersMutex.Unlock()
+
+	return c.readParameter(deviceId, fieldId)
+}
+
+// LoadParameters reads every field of a device, and arranges them into its folder tree.
+// Fields of data types that cannot be parsed are left out of the tree, hidden fields are kept but marked as hidden.
+func (c *Controller) LoadParameters(deviceId crossfire.Endpoint) (*settings.Tree, error) {
+	c.parametersMutex.Lock()
+	defer c.parametersMutex.Unlock()
+
+	info, err := c.readDeviceInfo(deviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("(parameters) loading %d fields of %s\n", info.FieldCount, info.Name)
+
+	var fields []settings.FieldType
+	var hidden []uint32
+Load:
+	for fieldId := 1; fieldId <= int(info.FieldCount); fieldId++ {
+		field, isHidden, err := c.readParameterEntry(deviceId, uint8(fieldId), nil)
+		if err != nil {
+			var readErr *ParameterReadError
+			var rangeErr *settings.OutOfRangeError
+			switch {
+			case errors.As(err, &readErr):
+				return nil, err
+			case errors.As(err, &rangeErr):
+				//the device has fewer fields than it announced
+				fmt.Printf("(parameters) field %d is out of range, stopping\n", fieldId)
+				break Load
+			}
+			fmt.Printf("(parameters) skipping field %d. %s\n", fieldId, err.Error())
+			continue
+		}
+		fields = append(fields, field)
+		if isHidden {
+			hidden = append(hidden, field.Id())
+		}
+	}
+
+	tree := settings.NewTree(info.Name, fields)
+	for _, id := range hidden {
+		tree.Hide(id)
+	}
+	return tree, nil
+}
+
+func (c *Controller) readDeviceInfo(deviceId crossfire.Endpoint) (*DeviceInfo, error) {
+	for attempt := 0; attempt < ParameterReadRetries; attempt++ {
+		//discard answers to earlier pings
+		drain(c.deviceInfoChan)
+
+		if err := c.request(PingDevices); err != nil {
+			return nil, err
+		}
+
+		timeout := time.After(ParameterReadTimeout)
+	Wait:
+		for {
+			select {
+			case info := <-c.deviceInfoChan:
+				if info.Id == deviceId {
+					return &info, nil
+				}
+			case <-timeout:
+				break Wait
+			}
+		}
+	}
+
+	return nil, &ParameterReadError{DeviceId: deviceId, Message: fmt.Sprintf("device %x did not answer the ping", uint8(deviceId))}
+}
+
+func (c *Controller) readParameter(deviceId crossfire.Endpoint, fieldId uint8) (settings.FieldType, error) {
+	return c.readParameterFrom(deviceId, fieldId, nil)
+}
+
+// writeParameterValue writes an encoded value, and returns the field as the device sent it back
+func (c *Controller) writeParameterValue(deviceId crossfire.Endpoint, fieldId uint8, value []byte) (settings.FieldType, error) {
+	//discard leftovers of earlier requests, so they are not taken for the answer to this write
+	drain(c.parameterChunkChan)
+
+	request := WriteDeviceFieldRequest{deviceId: uint8(deviceId), fieldId: fieldId, fieldValue: value}
+	if err := c.request(request); err != nil {
+		return nil, err
+	}
+
+	//devices answer a write with the updated field, only ask for it if they do not
+	timeout := time.After(ParameterReadTimeout)
+	for {
+		select {
+		case chunk := <-c.parameterChunkChan:
+			if chunk.deviceId == deviceId && chunk.fieldId == fieldId {
+				return c.readParameterFrom(deviceId, fieldId, &chunk)
+			}
+		case <-timeout:
+			return c.readParameter(deviceId, fieldId)
+		}
+	}
+}
+
+// readParameterFrom reads the chunks of a field, first is chunk 0 if it has already been received
+func (c *Controller) readParameterFrom(deviceId crossfire.Endpoint, fieldId uint8, first *parameterChunk) (settings.FieldType, error) {
+	field, _, err := c.readParameterEntry(deviceId, fieldId, first)
+	return field, err
+}
+
+// readParameterEntry works like readParameterFrom, and also tells whether the device flagged the field as hidden
+func (c *Controller) readParameterEntry(deviceId crossfire.Endpoint, fieldId uint8, first *parameterChunk) (settings.FieldType, bool, error) {
+	var data []byte
+	//unknown until the first chunk tells how many follow
+	expectedRemaining := -1
+	for chunkIndex := uint8(0); chunkIndex < MaxParameterChunks; chunkIndex++ {
+		chunk := first
+		if chunkIndex > 0 || chunk == nil {
+			var err error
+			if chunk, err = c.readParameterChunk(deviceId, fieldId, chunkIndex, expectedRemaining); err != nil {
+				return nil, false, err
+			}
+		}
+
+		data = append(data, chunk.data...)
+		expectedRemaining = int(chunk.chunksRemaining) - 1
+		if chunk.chunksRemaining == 0 {
+			if len(data) < 2 {
+				return nil, false, errors.New(fmt.Sprintf("field %d of device %x is too short", fieldId, uint8(deviceId)))
+			}
+			//the data starts with the parent id and the data type
+			dataType, hidden := settings.SplitDataType(telem.CRSFFieldType(data[1]))
+			field, err := settings.NewField(uint32(fieldId), uint32(data[0]), dataType, data[2:])
+			return field, hidden, err
+		}
+	}
+
+	return nil, false, errors.New(fmt.Sprintf("field %d of device %x has more than %d chunks", fieldId, uint8(deviceId), MaxParameterChunks))
+}
+
+// readParameterChunk requests a chunk, chunks that do not have the expected number of chunks remaining
+// (e.g. answers to an earlier request of the same field) are ignored, unless expectedRemaining is -1
+func (c *Controller) readParameterChunk(deviceId crossfire.Endpoint, fieldId uint8, chunkIndex uint8, expectedRemaining int) (*parameterChunk, error) {
+	for attempt := 0; attempt < ParameterReadRetries; attempt++ {
+		//discard chunks of earlier (timed out) requests
+		drain(c.parameterChunkChan)
+
+		request := ReadDeviceFieldsRequest{deviceId: uint8(deviceId), fieldId: fieldId, fieldChunk: chunkIndex}
+		if err := c.request(request); err != nil {
+			return nil, err
+		}
+
+		timeout := time.After(ParameterReadTimeout)
+	Wait:
+		for {
+			select {
+			case chunk := <-c.parameterChunkChan:
+				if chunk.deviceId == deviceId && chunk.fieldId == fieldId &&
+					(expectedRemaining < 0 || int(chunk.chunksRemaining) == expectedRemaining) {
+					return &chunk, nil
+				}
+			case <-timeout:
+				fmt.Printf("(parameters) no answer for chunk %d of field %d, attempt %d\n", chunkIndex, fieldId, attempt+1)
+				break Wait
+			}
+		}
+	}
+
+	return nil, &ParameterReadError{
+		DeviceId: deviceId,
+		FieldId:  fieldId,
+		Message:  fmt.Sprintf("device %x did not send chunk %d of field %d", uint8(deviceId), chunkIndex, fieldId),
+	}
+}
+
+// request hands a request to the send loop
+func (c *Controller) request(request any) error {
+	sendChan := c.sendChan
+	if sendChan == nil || !c.IsActive() {
+		return errors.New("link is not active")
+	}
+
+	select {
+	case sendChan <- request:
+		return nil
+	case <-time.After(ParameterReadTimeout):
+		return errors.New("send loop is busy")
+	}
+}
+
+func newDeviceInfo(info telem.TelemDeviceInfoExtType) DeviceInfo {
+	return DeviceInfo{
+		Id:               info.Src(),
+		Name:             info.DeviceName(),
+		SerialNumber:     info.SerialNumber(),
+		HardwareVersion:  info.HardwareVersion(),
+		SoftwareVersion:  info.SoftwareVersion(),
+		FieldCount:       info.FieldCount(),
+		ParameterVersion: info.ParameterVersion(),
+	}
+}
+
+func (c *Controller) sendDeviceInfo(info DeviceInfo) {
+	select {
+	case c.deviceInfoChan <- info:
+	default:
+	}
+}
+
+func (c *Controller) sendParameterChunk(entry telem.TelemDeviceSettingsEntryExtType) {
+	select {
+	case c.parameterChunkChan <- parameterChunk{
+		deviceId:        entry.Src(),
+		fieldId:         entry.Id(),
+		chunksRemaining: entry.ChunksRemaining(),
+		//the frame points into the reader's buffer, which is reused
+		data: append([]byte(nil), entry.Chunk()...),
+	}:
+	default:
+	}
+}
+
+func drain[T any](ch chan T) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// receivePassthrough decodes the passthrough telemetry of ArduPilot and hands the values to ArduPilotChan
+func (c *Controller) receivePassthrough(frame *crsf.Passthrough) {
+	for _, value := range c.passthrough.Decode(frame) {
+		if text, ok := value.(crsf.APStatusText); ok {
+			fmt.Printf("(ardupilot) %s: %s\n", text.SeverityName(), text.Text)
+		}
+
+		select {
+		case c.ArduPilotChan <- value:
+		default:
+		}
+	}
+}
@@ -12,7 +12,7 @@ import (
 	"time"
 )
 
-func (c *Controller) StartPortLoop(port *serial.Port, portChan chan any) error {
+func (c *Controller) StartPortLoop(port serial.Transport, portChan chan any) error {
 
 	if c.portLoopTomb != nil && c.portLoopTomb.Alive() {
 		return errors.New("port loop is already active")
@@ -38,20 +38,20 @@ func (c *Controller) StopPortLoop() error {
 	return nil
 }
 
-func (c *Controller) PortLoop(port *serial.Port, portChan chan any) error {
+func (c *Controller) PortLoop(port serial.Transport, portChan chan any) error {
 
 	fmt.Printf("(port-loop) starting, port %v\n", port)
 
 	var err error
 
-	fmt.Printf("(port-loop)(initial) opening port %s\n", port.Name)
+	fmt.Printf("(port-loop)(initial) opening port %s\n", port.PortName())
 	if err = port.Open(); err == nil {
-		fmt.Printf("(port-loop)(initial) port %s opened\n", port.Name)
+		fmt.Printf("(port-loop)(initial) port %s opened\n", port.PortName())
 		portChan <- nil
 		return nil
 	}
 
-	fmt.Printf("(port-loop)(initial) error opening port %s\n", port.Name)
+	fmt.Printf("(port-loop)(initial) error opening port %s\n", port.PortName())
 
 	//try re-opening with exponential backoff
 	attempts := 0
@@ -68,9 +68,9 @@ Loop:
 		case <-ticker.C:
 			attempts += 1
 			if attempts == 1 {
-				fmt.Printf("(port-loop)(backoff) closing, and re-opening port %s\n", port.Name)
+				fmt.Printf("(port-loop)(backoff) closing, and re-opening port %s\n", port.PortName())
 				if err = port.Close(); err != nil {
-					fmt.Printf("(supervisor)(backoff) error closing port on %s. %s\n", port.Name, err.Error())
+					fmt.Printf("(supervisor)(backoff) error closing port on %s. %s\n", port.PortName(), err.Error())
 				}
 			}
 
@@ -79,7 +79,7 @@ Loop:
 				currentDelay = initialDelay
 			}
 
-			fmt.Printf("(port-loop)(backoff) re-opening port %s (attempt: %d)\n", port.Name, attempts)
+			fmt.Printf("(port-loop)(backoff) re-opening port %s (attempt: %d)\n", port.PortName(), attempts)
 			if err = port.Open(); err != nil {
 				fmt.Printf("(port-loop)(backoff) error re-opening port (sleeping %s)\n", currentDelay)
 				ticker = time.NewTicker(currentDelay)
@@ -88,7 +88,7 @@ Loop:
 				continue
 			}
 
-			fmt.Printf("(port-loop)(backoff) port %s re-opened\n", port.Name)
+			fmt.Printf("(port-loop)(backoff) port %s re-opened\n", port.PortName())
 			portChan <- nil
 			break Loop
 		}
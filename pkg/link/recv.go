@@ -11,10 +11,11 @@ import (
 	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
 	"github.com/kaack/elrs-joystick-control/pkg/serial"
 	"gopkg.in/tomb.v2"
+	"io"
 	"time"
 )
 
-func (c *Controller) StartRecvLoop(port *serial.Port, sendChan chan any, recvChan chan any) error {
+func (c *Controller) StartRecvLoop(port serial.Transport, sendChan chan any, recvChan chan any) error {
 	if c.recvLoopTomb != nil && c.recvLoopTomb.Alive() {
 		return errors.New("recv loop is already active")
 	}
@@ -39,8 +40,8 @@ func (c *Controller) StopRecvLoop() error {
 	return nil
 }
 
-func (c *Controller) RecvLoop(port *serial.Port, sendChan chan any, recvChan chan any) error {
-	refreshRate := crossfire.GetRefreshRate(port.BaudRate)
+func (c *Controller) RecvLoop(port serial.Transport, sendChan chan any, recvChan chan any) error {
+	refreshRate := crossfire.GetRefreshRate(port.PortBaudRate())
 	maxInactivityTime := refreshRate * 4
 	fmt.Printf("(recv-loop) starting, refresh rate %v, max inactivity: %v\n", refreshRate, maxInactivityTime)
 	ticker := time.NewTicker(refreshRate)
@@ -86,19 +87,62 @@ Loop:
 				if _, ok := err.(*telem.InterruptedError); ok {
 					break
 				}
+				if errors.Is(err, io.EOF) {
+					//port went away (e.g. network peer disconnected), let the supervisor re-open it
+					fmt.Printf("(recv-loop) port %s closed\n", port.PortName())
+					break Loop
+				}
 				fmt.Printf("(recv-loop) error reading telemetry data. error: %s\n", err.Error())
 				c.errorPacketsCount += 1
 				break
 			}
 
+			if tPacket.Addr() != crossfire.HandsetEndpoint {
+				//e.g. the echo of our own frames on a half duplex line, it tells nothing about the link
+				c.sendUnhandledFrame(tPacket)
+				break
+			}
+
 			c.recvPacketsCount += 1
 			lastRecvTelemTime = currentTickTime
+			if c.recvPacketsCount == 1 {
+				c.sendLinkUp()
+			}
 
 			// Process telemetry based on type
 			switch tFrame := (tPacket).(type) {
 			case telem.TelemSyncType:
 				sendChan <- &tFrame
 
+			case telem.TelemCommandExtType:
+				if tFrame.IsSpeedResponse() {
+					fmt.Printf("(recv-loop) speed proposal accepted: %v\n", tFrame.SpeedAccepted())
+					c.sendSpeedAck(tFrame.SpeedAccepted())
+				}
+
+			case telem.TelemMavlinkEnvelopeType:
+				c.mavlink.receive(tFrame.Envelope())
+
+			case telem.TelemDisplayPortExtType:
+				c.receiveDisplayPort(tFrame.DisplayPort())
+
+			case telem.TelemPassthroughType:
+				c.receivePassthrough(tFrame.Passthrough())
+
+			case telem.TelemMSPExtType:
+				c.sendMSPFrame(tFrame.MSPFrame())
+
+			case telem.TelemStatusExtType:
+				c.updateLinkStatus(tFrame)
+
+			case telem.TelemDeviceInfoExtType:
+				info := newDeviceInfo(tFrame)
+				c.updateDevice(info)
+				c.sendDeviceInfo(info)
+
+			case telem.TelemDeviceSettingsEntryExtType:
+				c.sendParameterChunk(tFrame)
+
 			case telem.TelemLinkStatsType:
 				c.sendLinkStats(LinkStats{
 					UplinkRSSI1:  tFrame.UplinkRSSI1(),
@@ -132,6 +176,49 @@ Loop:
 					Yaw:   tFrame.Yaw(),
 				})
 
+			case telem.TelemRPMType:
+				c.sendRPM(RPMData{
+					SourceId: tFrame.SourceId(),
+					RPM:      tFrame.RPMs(),
+				})
+
+			case telem.TelemTemperatureType:
+				c.sendTemperature(TemperatureData{
+					SourceId:     tFrame.SourceId(),
+					Temperatures: tFrame.Temperatures(),
+				})
+
+			case telem.TelemCellsType:
+				c.sendCells(CellsData{
+					SourceId: tFrame.SourceId(),
+					Voltages: tFrame.CellVoltages(),
+				})
+
+			case telem.TelemAirspeedType:
+				c.sendAirspeed(AirspeedData{Speed: tFrame.Airspeed()})
+
+			case telem.TelemHeartbeatType:
+				c.touchDevice(tFrame.Origin())
+				c.sendHeartbeat(HeartbeatData{Origin: tFrame.Origin()})
+
+			case telem.TelemGPSTimeType:
+				c.sendGPSTime(GPSTimeData{Time: tFrame.GPSTime()})
+
+			case telem.TelemGPSExtendedType:
+				c.sendGPSExtended(GPSExtendedData{
+					FixType:                 tFrame.FixType(),
+					NorthVelocity:           tFrame.NorthVelocity(),
+					EastVelocity:            tFrame.EastVelocity(),
+					UpVelocity:              tFrame.UpVelocity(),
+					HorizontalSpeedAccuracy: tFrame.HorizontalSpeedAccuracy(),
+					TrackAccuracy:           tFrame.TrackAccuracy(),
+					AltitudeEllipsoid:       tFrame.AltitudeEllipsoid(),
+					HorizontalAccuracy:      tFrame.HorizontalAccuracy(),
+					VerticalAccuracy:        tFrame.VerticalAccuracy(),
+					HDOP:                    tFrame.HDOP(),
+					VDOP:                    tFrame.VDOP(),
+				})
+
 			// Ignore other telemetry types
 			case telem.TelemFlightModeType,
 				telem.TelemLinkTXType,
@@ -142,7 +229,8 @@ Loop:
 				// Skip these
 
 			default:
-				// Unknown telemetry
+				// Raw frames, and frames of decoders registered by the application
+				c.sendUnhandledFrame(tFrame)
 			}
 		}
 	}
@@ -0,0 +1,325 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"github.com/kaack/elrs-joystick-control/pkg/serial"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"gopkg.in/tomb.v2"
+	"io"
+	"sync"
+	"time"
+)
+
+// RelayHandsetTimeout how long the handset may go without sending channels before the relay disarms
+const RelayHandsetTimeout = 500 * time.Millisecond
+
+// ChannelMixMode how the value set on a channel is combined with the handset's value
+type ChannelMixMode int32
+
+const (
+	MixHandset ChannelMixMode = iota // the handset's value, as if the channel was not mixed
+	MixReplace ChannelMixMode = iota // the value replaces the handset's value
+	MixOffset  ChannelMixMode = iota // the value is added to the handset's value, the center of the range adds nothing
+)
+
+func (m ChannelMixMode) String() string {
+	switch m {
+	case MixHandset:
+		return "handset"
+	case MixReplace:
+		return "replace"
+	case MixOffset:
+		return "offset"
+	default:
+		return fmt.Sprintf("%d", int(m))
+	}
+}
+
+// ChannelMix combines a channel of the handset with a value at ChannelModelResolution
+type ChannelMix struct {
+	Mode  ChannelMixMode
+	Value util.CRSFValue
+}
+
+func (m ChannelMix) apply(handset util.CRSFValue) util.CRSFValue {
+	switch m.Mode {
+	case MixReplace:
+		return min(m.Value, ChannelModelResolution.MaxValue())
+	case MixOffset:
+		center := int32(ChannelModelResolution.MaxValue()+1) / 2
+		value := int32(handset) + int32(m.Value) - center
+		return util.CRSFValue(max(0, min(value, int32(ChannelModelResolution.MaxValue()))))
+	default:
+		return handset
+	}
+}
+
+// Relay sits between a handset and a TX module (e.g. a trainer setup, or an autopilot taking over some channels).
+// It reads the channels of the handset on one port and hands them, mixed, to the Controller of the module,
+// frames the handset sends to the module go through unchanged, and so do the frames the module sends back.
+type Relay struct {
+	Handset serial.Transport
+	Module  *Controller
+
+	FramesCount uint64
+	ErrorsCount uint64
+
+	mixes      map[int]ChannelMix
+	mixesMutex sync.RWMutex
+
+	tomb *tomb.Tomb
+}
+
+func NewRelay(handset serial.Transport, module *Controller) *Relay {
+	return &Relay{
+		Handset: handset,
+		Module:  module,
+		mixes:   map[int]ChannelMix{},
+	}
+}
+
+// SetMix sets how a channel is mixed, it takes effect with the next channels of the handset
+func (r *Relay) SetMix(channel int, mix ChannelMix) error {
+	if channel < 0 || channel >= crsf.MaxChannels {
+		return errors.New(fmt.Sprintf("channel %d is out of range, there are %d channels", channel, crsf.MaxChannels))
+	}
+
+	r.mixesMutex.Lock()
+	defer r.mixesMutex.Unlock()
+	if mix.Mode == MixHandset {
+		delete(r.mixes, channel)
+		return nil
+	}
+	r.mixes[channel] = mix
+	return nil
+}
+
+// ClearMixes hands all channels back to the handset
+func (r *Relay) ClearMixes() {
+	r.mixesMutex.Lock()
+	defer r.mixesMutex.Unlock()
+	r.mixes = map[int]ChannelMix{}
+}
+
+// ModuleTransport wraps the port of the module, everything the module sends to the handset is copied to
+// the handset port. The Controller has to be started on the wrapped transport.
+func (r *Relay) ModuleTransport(module serial.Transport) serial.Transport {
+	return &relayTransport{Transport: module, relay: r}
+}
+
+// Start opens the handset port and relays until Stop
+func (r *Relay) Start() error {
+	if r.tomb != nil && r.tomb.Alive() {
+		return errors.New("relay is already active")
+	}
+
+	if err := r.Handset.SetReadTimeout(100 * time.Millisecond); err != nil {
+		return err
+	}
+	if err := r.Handset.Open(); err != nil {
+		return err
+	}
+
+	r.Module.setRelayed(true)
+	r.tomb = &tomb.Tomb{}
+	r.tomb.Go(r.relayLoop)
+	return nil
+}
+
+func (r *Relay) Stop() error {
+	if r.tomb == nil {
+		return nil
+	}
+
+	r.tomb.Kill(nil)
+	err := r.tomb.Wait()
+	_ = r.Handset.Close()
+	r.Module.setRelayed(false)
+	return err
+}
+
+func (r *Relay) relayLoop() error {
+	var frames = make(chan telem.TelemType)
+	var readErr error
+
+	//the reader blocks on the port, the timeout is checked on its own
+	readTomb := &tomb.Tomb{}
+	readTomb.Go(func() error {
+		defer close(frames)
+
+		reader := telem.NewReader(r.Handset)
+		reader.IsFrameStart = telem.IsSyncAddress
+		//only channels are decoded, anything else goes to the module as it came
+		reader.Decode = func(data []byte) (telem.TelemType, error) {
+			switch crsf.FrameType(data[2]) {
+			case crsf.ChannelsFrame, crsf.SubsetChannelsFrame:
+				return telem.DefaultRegistry.Decode(data)
+			default:
+				frame := telem.RawFrame{RawData: append([]byte(nil), data...)}
+				return &frame, nil
+			}
+		}
+
+		for {
+			frame, err := reader.Next(readTomb)
+			if err != nil {
+				if _, ok := err.(*telem.InterruptedError); ok {
+					return nil
+				}
+				if errors.Is(err, io.EOF) {
+					readErr = err
+					return nil
+				}
+				fmt.Printf("(relay) error reading handset frame. error: %s\n", err.Error())
+				r.ErrorsCount += 1
+				continue
+			}
+
+			select {
+			case frames <- frame:
+			case <-readTomb.Dying():
+				return nil
+			}
+		}
+	})
+	defer func() {
+		readTomb.Kill(nil)
+		_ = readTomb.Wait()
+	}()
+
+	var handset [crsf.MaxChannels]util.CRSFValue
+	var lastChannels time.Time
+	timedOut := false
+	ticker := time.NewTicker(RelayHandsetTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.tomb.Dying():
+			return nil
+
+		case <-ticker.C:
+			//the channels of the controller are left alone until the handset sent some
+			if !timedOut && !lastChannels.IsZero() && time.Since(lastChannels) > RelayHandsetTimeout {
+				fmt.Printf("(relay) no channels from the handset for %v, disarming\n", RelayHandsetTimeout)
+				timedOut = true
+				disarmed := r.mix(handset)
+				disarm(&disarmed)
+				r.Module.UpdateChannelsHighRes(disarmed)
+			}
+
+		case frame, ok := <-frames:
+			if !ok {
+				fmt.Printf("(relay) handset port %s closed\n", r.Handset.PortName())
+				return readErr
+			}
+			r.FramesCount += 1
+
+			switch tFrame := frame.(type) {
+			case telem.TelemChannelsType:
+				for i, value := range tFrame.Channels() {
+					if channel := tFrame.StartChannel() + i; channel < len(handset) {
+						handset[channel] = crsf.ScaleChannel(value, crsf.Resolution11Bit, ChannelModelResolution)
+					}
+				}
+				if timedOut {
+					fmt.Printf("(relay) channels from the handset are back\n")
+					timedOut = false
+				}
+				lastChannels = time.Now()
+				r.Module.UpdateChannelsHighRes(r.mix(handset))
+
+			case *telem.RawFrame:
+				if modelId, ok := modelSelection(tFrame.RawData); ok {
+					r.Module.trackModelId(modelId)
+				}
+				if err := r.Module.request(RelayRequest{frame: tFrame.RawData}); err != nil {
+					fmt.Printf("(relay) dropped a %v frame of the handset. %s\n", tFrame.Type(), err.Error())
+				}
+			}
+		}
+	}
+}
+
+// modelSelection returns the model id of a model select command
+func modelSelection(data []byte) (uint8, bool) {
+	if crsf.FrameType(data[2]) != crsf.CommandFrame {
+		return 0, false
+	}
+
+	var command crsf.Command
+	if err := command.Unmarshal(data); err != nil {
+		return 0, false
+	}
+	if command.SubCommand != crsf.SubcommandFrame || command.Command != crsf.CmdModelSelectFrame || len(command.Payload) < 1 {
+		return 0, false
+	}
+	return command.Payload[0], true
+}
+
+// mix applies the mixes to the handset's channels
+func (r *Relay) mix(handset [crsf.MaxChannels]util.CRSFValue) [crsf.MaxChannels]util.CRSFValue {
+	r.mixesMutex.RLock()
+	defer r.mixesMutex.RUnlock()
+
+	mixed := handset
+	for channel, mix := range r.mixes {
+		mixed[channel] = mix.apply(handset[channel])
+	}
+	return mixed
+}
+
+// writeHandset is only called by the recv loop of the module
+func (r *Relay) writeHandset(frame []byte) {
+	if _, err := r.Handset.Write(frame); err != nil {
+		fmt.Printf("(relay) error writing to handset port %s. %s\n", r.Handset.PortName(), err.Error())
+	}
+}
+
+// relayTransport copies the frames the module sends to the handset, as they were read
+type relayTransport struct {
+	serial.Transport
+	relay *Relay
+
+	buffer []byte
+}
+
+func (t *relayTransport) Read(bytes []byte) (int, error) {
+	count, err := t.Transport.Read(bytes)
+	if count > 0 {
+		t.forward(bytes[:count])
+	}
+	return count, err
+}
+
+func (t *relayTransport) Open() error {
+	t.buffer = nil
+	return t.Transport.Open()
+}
+
+func (t *relayTransport) forward(data []byte) {
+	t.buffer = append(t.buffer, data...)
+
+	for len(t.buffer) > 0 {
+		skip, frame, _ := telem.SplitAt(t.buffer, false, telem.IsSyncAddress)
+		if skip == 0 {
+			//need more data
+			break
+		}
+		//frames to anywhere else are e.g. the echo of our own frames on a half duplex line
+		if frame != nil && crsf.Endpoint((*frame)[0]) == crsf.HandsetEndpoint && t.relay.tomb != nil && t.relay.tomb.Alive() {
+			t.relay.writeHandset(*frame)
+		}
+		t.buffer = t.buffer[skip:]
+	}
+
+	//keep the buffer from growing, the unconsumed tail is short
+	t.buffer = append([]byte(nil), t.buffer...)
+}
@@ -10,11 +10,22 @@ import (
 	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
 	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
 	"github.com/kaack/elrs-joystick-control/pkg/serial"
+	"golang.org/x/exp/slices"
 	"gopkg.in/tomb.v2"
 	"time"
 )
 
-func (c *Controller) StartSendLoop(port *serial.Port, sendChan chan any, recvChan chan any) error {
+// MaxQueuedFrames how many frames (MSP, MAVLink, display port, relayed) may wait for their slot after a channels frame,
+// a request that does not fit is dropped as a whole
+const MaxQueuedFrames = 64
+
+// queuedFrame is sent after a channels frame, MSP frames are dropped from the queue when their request times out
+type queuedFrame struct {
+	data []byte
+	msp  bool
+}
+
+func (c *Controller) StartSendLoop(port serial.Transport, sendChan chan any, recvChan chan any) error {
 	if c.sendLoopTomb != nil && c.sendLoopTomb.Alive() {
 		return errors.New("send loop is already active")
 	}
@@ -39,14 +50,29 @@ func (c *Controller) StopSendLoop() error {
 	return nil
 }
 
-func (c *Controller) SendLoop(port *serial.Port, sendChan chan any, recvChan chan any) error {
-	currentRefreshRate := crsf.GetRefreshRate(port.BaudRate)
-	nextRefreshRate := currentRefreshRate
+func (c *Controller) SendLoop(port serial.Transport, sendChan chan any, recvChan chan any) error {
+	currentRefreshRate := crsf.GetRefreshRate(port.PortBaudRate())
 
-	fmt.Printf("(send-loop) starting, refresh rate %v\n", currentRefreshRate)
+	fmt.Printf("(send-loop) starting, refresh rate %v, %s\n", currentRefreshRate, c.GetChannelEncoding())
 
 	var err error
-	ticker := time.NewTicker(currentRefreshRate)
+	var queuedFrames []queuedFrame
+	queue := func(name string, msp bool, frames ...[]byte) {
+		if len(queuedFrames)+len(frames) > MaxQueuedFrames {
+			c.errorPacketsCount += uint64(len(frames))
+			fmt.Printf("(send-loop) dropping %d %s frame(s), %d frames are queued already\n", len(frames), name, len(queuedFrames))
+			return
+		}
+		for _, frame := range frames {
+			queuedFrames = append(queuedFrames, queuedFrame{data: frame, msp: msp})
+		}
+	}
+
+	//the channels frames follow the packets of the module, once it reports them in sync frames
+	phase := newPhaseLock(currentRefreshRate, time.Now())
+	timer := time.NewTimer(time.Until(phase.Next(time.Now())))
+	defer timer.Stop()
+	c.setPhaseLockState(phase.State())
 
 	c.sentPacketsCount = 0
 
@@ -59,36 +85,88 @@ Loop:
 		case chData := <-sendChan:
 			switch data := (chData).(type) {
 			case ChannelRequest:
+				if c.isRelayed() {
+					//the handset's own model id and ping frames are relayed
+					break
+				}
 				if data == SendModelId {
-					fmt.Printf("(send-loop) writing model id frame\n")
-					if _, err = port.Write(crsf.CreateModelIDFrame(0)); err != nil {
+					modelId := c.GetModelId()
+					fmt.Printf("(send-loop) writing model id frame (model %d)\n", modelId)
+					if _, err = port.Write(crsf.CreateModelIDFrame(modelId)); err != nil {
 						c.errorPacketsCount += 1
-						fmt.Printf("(send-loop) could not write model id frame on port %s. %s\n", port.Name, err.Error())
+						fmt.Printf("(send-loop) could not write model id frame on port %s. %s\n", port.PortName(), err.Error())
 					}
 				} else if data == PingDevices {
 					fmt.Printf("(send-loop) pinging devices\n")
 					if _, err = port.Write(crsf.CreatePingDevicesFrame()); err != nil {
 						c.errorPacketsCount += 1
-						fmt.Printf("(send-loop) could not write ping devices frame on port %s. %s\n", port.Name, err.Error())
+						fmt.Printf("(send-loop) could not write ping devices frame on port %s. %s\n", port.PortName(), err.Error())
 					}
 				}
+			case SpeedProposalRequest:
+				fmt.Printf("(send-loop) writing speed proposal frame (%d baud)\n", data.baudRate)
+				if _, err = port.Write(crsf.CreateSpeedProposalFrame(0, data.baudRate)); err != nil {
+					c.errorPacketsCount += 1
+					fmt.Printf("(send-loop) could not write speed proposal frame on port %s. %s\n", port.PortName(), err.Error())
+				}
+			case ReadDeviceFieldsRequest:
+				if _, err = port.Write(crsf.CreateParameterSettingsReadFrame(data.deviceId, data.fieldId, data.fieldChunk)); err != nil {
+					c.errorPacketsCount += 1
+					fmt.Printf("(send-loop) could not write parameter read frame on port %s. %s\n", port.PortName(), err.Error())
+				}
+			case WriteDeviceFieldRequestUint8:
+				c.writeParameterFrame(port, crsf.CreateParameterSettingWriteFrameUint8(data.deviceId, data.fieldId, data.fieldValue))
+			case WriteDeviceFieldRequestUint16:
+				c.writeParameterFrame(port, crsf.CreateParameterSettingWriteFrameUint16(data.deviceId, data.fieldId, data.fieldValue))
+			case WriteDeviceFieldRequest:
+				c.writeParameterFrame(port, crsf.CreateParameterSettingWriteFrame(data.deviceId, data.fieldId, data.fieldValue))
+			case MSPRequest:
+				//sent one frame per channels frame, like a radio fits them between its channel updates
+				queue("msp", true, data.frames...)
+			case MSPCancelRequest:
+				queuedFrames = slices.DeleteFunc(queuedFrames, func(frame queuedFrame) bool {
+					return frame.msp
+				})
+			case MAVLinkRequest:
+				queue("mavlink", false, data.frames...)
+			case DisplayPortRequest:
+				queue("display port", false, data.frame)
+			case RelayRequest:
+				queue("relayed", false, data.frame)
 			case *telem.TelemSyncType:
-				nextRefreshRate = crsf.AdjustSendRate((*data).Rate(), (*data).Offset())
-				ticker.Reset(nextRefreshRate)
+				phase.Sync((*data).Rate(), (*data).Offset(), time.Now())
+				c.setPhaseLockState(phase.State())
 			default:
 				// Ignore other requests
 			}
 
-		case <-ticker.C:
-			channels := c.GetChannels()
-			if _, err = port.Write(crsf.PackChannels(&channels)); err != nil {
-				fmt.Printf("(send-loop) could not write channels on port %s. %s\n", port.Name, err.Error())
+		case <-timer.C:
+			timer.Reset(time.Until(phase.Next(time.Now())))
+			c.setPhaseLockState(phase.State())
+
+			if _, err = port.Write(c.createChannelsFrame()); err != nil {
+				fmt.Printf("(send-loop) could not write channels on port %s. %s\n", port.PortName(), err.Error())
 				break Loop
 			}
 			c.sentPacketsCount += 1
+
+			if len(queuedFrames) > 0 {
+				if _, err = port.Write(queuedFrames[0].data); err != nil {
+					c.errorPacketsCount += 1
+					fmt.Printf("(send-loop) could not write frame %x on port %s. %s\n", queuedFrames[0].data, port.PortName(), err.Error())
+				}
+				queuedFrames = queuedFrames[1:]
+			}
 		}
 	}
 
 	fmt.Println("(send-loop): exiting send loop ...")
 	return nil
 }
+
+func (c *Controller) writeParameterFrame(port serial.Transport, frame []byte) {
+	if _, err := port.Write(frame); err != nil {
+		c.errorPacketsCount += 1
+		fmt.Printf("(send-loop) could not write parameter write frame on port %s. %s\n", port.PortName(), err.Error())
+	}
+}
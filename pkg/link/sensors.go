@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+// Sensor telemetry types, sensors with several values (e.g. one per motor) tell which sensor sent them by SourceId
+type RPMData struct {
+	SourceId uint8
+	RPM      []int32
+}
+
+type TemperatureData struct {
+	SourceId     uint8
+	Temperatures []float32 // in degrees celsius
+}
+
+type CellsData struct {
+	SourceId uint8
+	Voltages []float32 // in volts
+}
+
+type AirspeedData struct {
+	Speed float32 // in km/h
+}
+
+type HeartbeatData struct {
+	Origin crsf.Endpoint
+}
+
+type GPSTimeData struct {
+	Time time.Time // in UTC
+}
+
+type GPSExtendedData struct {
+	FixType                 uint8
+	NorthVelocity           float32 // in meters/second
+	EastVelocity            float32 // in meters/second
+	UpVelocity              float32 // in meters/second
+	HorizontalSpeedAccuracy float32 // in meters/second
+	TrackAccuracy           float32 // in degrees
+	AltitudeEllipsoid       int32   // in meters above the GPS ellipsoid
+	HorizontalAccuracy      float32 // in meters
+	VerticalAccuracy        float32 // in meters
+	HDOP                    float32
+	VDOP                    float32
+}
+
+func (c *Controller) sendRPM(rpm RPMData) {
+	select {
+	case c.RPMChan <- rpm:
+	default:
+	}
+}
+
+func (c *Controller) sendTemperature(temperature TemperatureData) {
+	select {
+	case c.TemperatureChan <- temperature:
+	default:
+	}
+}
+
+func (c *Controller) sendCells(cells CellsData) {
+	select {
+	case c.CellsChan <- cells:
+	default:
+	}
+}
+
+func (c *Controller) sendAirspeed(airspeed AirspeedData) {
+	select {
+	case c.AirspeedChan <- airspeed:
+	default:
+	}
+}
+
+func (c *Controller) sendHeartbeat(heartbeat HeartbeatData) {
+	select {
+	case c.HeartbeatChan <- heartbeat:
+	default:
+	}
+}
+
+func (c *Controller) sendGPSTime(gpsTime GPSTimeData) {
+	select {
+	case c.GPSTimeChan <- gpsTime:
+	default:
+	}
+}
+
+func (c *Controller) sendGPSExtended(gpsExtended GPSExtendedData) {
+	select {
+	case c.GPSExtendedChan <- gpsExtended:
+	default:
+	}
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	telem "github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"github.com/kaack/elrs-joystick-control/pkg/serial"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"gopkg.in/tomb.v2"
+	"io"
+	"time"
+)
+
+// SniffDirection is a guess of which way a frame went on the line between a handset and its module
+type SniffDirection int32
+
+const (
+	SniffUnknown   SniffDirection = iota
+	SniffToModule  SniffDirection = iota // sent by the handset
+	SniffToHandset SniffDirection = iota // answered by the module (or the receiver and flight controller behind it)
+)
+
+func (d SniffDirection) String() string {
+	switch d {
+	case SniffToModule:
+		return "handset->module"
+	case SniffToHandset:
+		return "module->handset"
+	default:
+		return "unknown"
+	}
+}
+
+// SniffedFrame a frame seen on the line. Channels is set for the channels frames of the handset,
+// as 11-bit values, channels a subset frame does not carry keep the value last seen.
+type SniffedFrame struct {
+	Time      time.Time
+	Direction SniffDirection
+	Frame     telem.TelemType
+	Channels  *[16]util.CRSFValue
+}
+
+// Sniffer listens on the line between a handset and its module (e.g. a USB-UART tapped into the JR bay),
+// it decodes the frames of both directions and never writes to the port
+type Sniffer struct {
+	Port      serial.Transport
+	FrameChan chan SniffedFrame
+
+	FramesCount uint64
+	ErrorsCount uint64
+
+	tomb *tomb.Tomb
+}
+
+func NewSniffer(port serial.Transport) *Sniffer {
+	return &Sniffer{
+		Port:      &listenOnlyTransport{Transport: port},
+		FrameChan: make(chan SniffedFrame, 64),
+	}
+}
+
+// Start opens the port and decodes frames until Stop, FrameChan is closed once it stops
+func (s *Sniffer) Start() error {
+	if s.tomb != nil && s.tomb.Alive() {
+		return errors.New("sniffer is already active")
+	}
+
+	if err := s.Port.SetReadTimeout(100 * time.Millisecond); err != nil {
+		return err
+	}
+	if err := s.Port.Open(); err != nil {
+		return err
+	}
+
+	s.tomb = &tomb.Tomb{}
+	s.tomb.Go(s.sniffLoop)
+	return nil
+}
+
+func (s *Sniffer) Stop() error {
+	if s.tomb == nil {
+		return nil
+	}
+
+	s.tomb.Kill(nil)
+	err := s.tomb.Wait()
+	_ = s.Port.Close()
+	return err
+}
+
+func (s *Sniffer) sniffLoop() error {
+	defer close(s.FrameChan)
+
+	//frames to any endpoint, decoded whichever way they go
+	reader := telem.NewReader(s.Port)
+	reader.IsFrameStart = telem.IsSyncAddress
+	reader.Decode = telem.DefaultRegistry.Decode
+
+	var channels [16]util.CRSFValue
+
+	for {
+		frame, err := reader.Next(s.tomb)
+		if err != nil {
+			if _, ok := err.(*telem.InterruptedError); ok {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				fmt.Printf("(sniffer) port %s closed\n", s.Port.PortName())
+				return nil
+			}
+			fmt.Printf("(sniffer) error reading frame. error: %s\n", err.Error())
+			s.ErrorsCount += 1
+			continue
+		}
+
+		s.FramesCount += 1
+		sniffed := SniffedFrame{
+			Time:      time.Now(),
+			Direction: GuessDirection(frame),
+			Frame:     frame,
+		}
+
+		if channelsFrame, ok := frame.(telem.TelemChannelsType); ok {
+			for i, value := range channelsFrame.Channels() {
+				if channel := channelsFrame.StartChannel() + i; channel < len(channels) {
+					channels[channel] = value
+				}
+			}
+			current := channels
+			sniffed.Channels = &current
+		}
+
+		select {
+		case s.FrameChan <- sniffed:
+		case <-s.tomb.Dying():
+			return nil
+		}
+	}
+}
+
+// GuessDirection tells the way of a frame from its address, and for extended frames from its source
+func GuessDirection(frame telem.TelemType) SniffDirection {
+	switch frame.Addr() {
+	case crsf.HandsetEndpoint:
+		return SniffToHandset
+	case crsf.ModuleEndpoint:
+		return SniffToModule
+	}
+
+	if _, ok := frame.(telem.TelemChannelsType); ok {
+		//only the handset sends channels
+		return SniffToModule
+	}
+
+	src := crsf.AllEndpoint
+	if extFrame, ok := frame.(telem.TelemExtType); ok {
+		src = extFrame.Src()
+	} else if rawFrame, ok := frame.(*telem.RawFrame); ok && frame.Type() >= crsf.PingDevicesFrame && len(rawFrame.RawData) >= telem.MinExtendedFrameSize {
+		//address, length, type, dst, src, crc
+		src = crsf.Endpoint(rawFrame.RawData[4])
+	}
+
+	switch src {
+	case crsf.HandsetEndpoint, crsf.LuaEndpoint:
+		return SniffToModule
+	case crsf.ModuleEndpoint, crsf.ReceiverEndpoint, crsf.FlightControllerEndpoint:
+		return SniffToHandset
+	}
+
+	return SniffUnknown
+}
+
+// listenOnlyTransport keeps anything from writing to a sniffed line, a write would collide with the handset
+type listenOnlyTransport struct {
+	serial.Transport
+}
+
+func (t *listenOnlyTransport) Write(bytes []byte) (int, error) {
+	return 0, errors.New(fmt.Sprintf("port %s is listen only", t.PortName()))
+}
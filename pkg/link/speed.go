@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/serial"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// SpeedResponseTimeout how long to wait for the module to answer a speed proposal
+const SpeedResponseTimeout = 1 * time.Second
+
+// SpeedVerifyTimeout how long to wait for telemetry at the new baud rate, before falling back to the previous one
+const SpeedVerifyTimeout = 2 * time.Second
+
+type speedNegotiation struct {
+	baudRate int32
+	result   chan error
+}
+
+// NegotiateBaudRate asks the TX module to switch to a new baud rate. Once the module accepts,
+// the port is re-opened at the new rate. If the link does not come back up, the previous rate is restored,
+// and an error is returned.
+func (c *Controller) NegotiateBaudRate(baudRate int32) error {
+	if !slices.Contains(crossfire.GetBaudRates(), baudRate) {
+		return errors.New(fmt.Sprintf("unsupported baud rate %d", baudRate))
+	}
+
+	speedChan := c.speedChan
+	if speedChan == nil || !c.IsActive() {
+		return errors.New("link is not active")
+	}
+
+	negotiation := &speedNegotiation{baudRate: baudRate, result: make(chan error, 1)}
+	select {
+	case speedChan <- negotiation:
+	case <-time.After(SpeedResponseTimeout):
+		return errors.New("link supervisor is busy")
+	}
+
+	return <-negotiation.result
+}
+
+// hasBaudRate tells if the baud rate of a port can follow the module, network ports and replays cannot
+func hasBaudRate(port serial.Transport) bool {
+	return !serial.IsNetworkName(port.PortName()) && !capture.IsReplayName(port.PortName())
+}
+
+// proposeSpeed sends the speed proposal, and waits for the module to accept it
+func (c *Controller) proposeSpeed(sendChan chan any, baudRate int32) error {
+	//discard stale acknowledgements
+	select {
+	case <-c.speedAckChan:
+	default:
+	}
+
+	fmt.Printf("(supervisor) proposing %d baud\n", baudRate)
+	sendChan <- SpeedProposalRequest{baudRate: baudRate}
+
+	select {
+	case accepted := <-c.speedAckChan:
+		if !accepted {
+			return errors.New(fmt.Sprintf("module rejected %d baud", baudRate))
+		}
+		return nil
+	case <-time.After(SpeedResponseTimeout):
+		return errors.New(fmt.Sprintf("module did not answer the %d baud proposal", baudRate))
+	}
+}
+
+func (c *Controller) sendSpeedAck(accepted bool) {
+	select {
+	case c.speedAckChan <- accepted:
+	default:
+	}
+}
+
+func (c *Controller) sendLinkUp() {
+	select {
+	case c.linkUpChan <- nil:
+	default:
+	}
+}
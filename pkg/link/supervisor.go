@@ -7,13 +7,14 @@ package link
 import (
 	"errors"
 	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/capture"
 	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
 	"github.com/kaack/elrs-joystick-control/pkg/serial"
 	"gopkg.in/tomb.v2"
 	"time"
 )
 
-func (c *Controller) StartSupervisor(port string, baudRate int32) error {
+func (c *Controller) StartSupervisor(port serial.Transport) error {
 
 	if c.supervisorTomb != nil && c.supervisorTomb.Alive() {
 		return errors.New("link is already active")
@@ -21,7 +22,7 @@ func (c *Controller) StartSupervisor(port string, baudRate int32) error {
 
 	c.supervisorTomb = &tomb.Tomb{}
 	c.supervisorTomb.Go(func() error {
-		return c.SupervisorLoop(port, baudRate)
+		return c.SupervisorLoop(port)
 	})
 
 	return nil
@@ -46,19 +47,30 @@ func action(action string, err error) {
 	}
 }
 
-func (c *Controller) SupervisorLoop(port string, baudRate int32) error {
-	fmt.Printf("(supervisor) starting, port: %s, baud: %v ...\n", port, baudRate)
+func (c *Controller) SupervisorLoop(sport serial.Transport) error {
+	fmt.Printf("(supervisor) starting, port: %s, baud: %v ...\n", sport.PortName(), sport.PortBaudRate())
 	c.supervisorState = SupervisorActive
 
-	refreshRate := crossfire.GetRefreshRate(baudRate)
-	sport := &serial.Port{Name: port, BaudRate: baudRate, ReadTimeout: refreshRate * 4}
+	refreshRate := crossfire.GetRefreshRate(sport.PortBaudRate())
+	action("setting read timeout", sport.SetReadTimeout(refreshRate*4))
+
+	if c.recorder != nil {
+		sport = capture.NewRecordingTransport(sport, c.recorder)
+	}
 
 	sendChan := make(chan any)
 	recvChan := make(chan any)
 	portChan := make(chan any)
+	speedChan := make(chan *speedNegotiation)
 
 	c.sendChan = sendChan
 	c.recvChan = recvChan
+	c.speedChan = speedChan
+
+	//baud rate negotiation in progress, waiting for the link to come up at the new rate
+	var verifying *speedNegotiation
+	var verifyTimeout <-chan time.Time
+	previousBaudRate := sport.PortBaudRate()
 
 Supervisor:
 	for {
@@ -88,20 +100,45 @@ Supervisor:
 		sendChan <- PingDevices
 		time.Sleep(100 * time.Millisecond)
 		
-		// Send model ID periodically to maintain link
+		// Send model ID periodically to maintain link, and keep track of the devices on it.
+		// Stops with the session, its send loop is gone then and sendChan belongs to the next one.
 		handshakeTicker := time.NewTicker(1 * time.Second)
+		devicesTicker := time.NewTicker(DevicePingInterval)
+		sessionDone := make(chan any)
 		go func() {
+			defer handshakeTicker.Stop()
+			defer devicesTicker.Stop()
+
 			for {
+				var request any
 				select {
 				case <-handshakeTicker.C:
-					sendChan <- SendModelId
+					request = SendModelId
+				case <-devicesTicker.C:
+					c.expireDevices()
+					request = PingDevices
+				case <-sessionDone:
+					return
+				case <-c.supervisorTomb.Dying():
+					return
+				}
+
+				select {
+				case sendChan <- request:
+				case <-sessionDone:
+					return
 				case <-c.supervisorTomb.Dying():
-					handshakeTicker.Stop()
 					return
 				}
 			}
 		}()
 
+		if verifying != nil {
+			verifyTimeout = time.After(SpeedVerifyTimeout)
+		}
+
+		nextBaudRate := int32(0)
+
 	Loop:
 		for {
 			select {
@@ -113,22 +150,68 @@ Supervisor:
 				break Loop
 			case <-c.supervisorTomb.Dying():
 				fmt.Printf("(supervisor) exiting loop...\n")
+				close(sessionDone)
 				break Supervisor
+			case <-c.linkUpChan:
+				if verifying != nil {
+					fmt.Printf("(supervisor) link is up at %d baud\n", sport.PortBaudRate())
+					verifying.result <- nil
+					verifying = nil
+					verifyTimeout = nil
+				}
+			case <-verifyTimeout:
+				fmt.Printf("(supervisor) no telemetry at %d baud, falling back to %d baud\n", sport.PortBaudRate(), previousBaudRate)
+				verifying.result <- errors.New(fmt.Sprintf("link did not come up at %d baud", sport.PortBaudRate()))
+				verifying = nil
+				verifyTimeout = nil
+				nextBaudRate = previousBaudRate
+				break Loop
+			case negotiation := <-speedChan:
+				if !hasBaudRate(sport) {
+					//the module would switch, the far end of the connection (or the capture) would not
+					negotiation.result <- errors.New(fmt.Sprintf("cannot switch the baud rate of port %s", sport.PortName()))
+					break
+				}
+				if err := c.proposeSpeed(sendChan, negotiation.baudRate); err != nil {
+					fmt.Printf("(supervisor) %s\n", err.Error())
+					negotiation.result <- err
+					break
+				}
+				previousBaudRate = sport.PortBaudRate()
+				verifying = negotiation
+				nextBaudRate = negotiation.baudRate
+				break Loop
 			}
 		}
 
-		handshakeTicker.Stop()
+		close(sessionDone)
 		action("stopping recv loop", c.StopRecvLoop())
 		action("stopping send loop", c.StopSendLoop())
 		action("closing serial port", sport.Close())
 
+		//discard the link-up event of the session that just ended
+		select {
+		case <-c.linkUpChan:
+		default:
+		}
+
+		if nextBaudRate != 0 {
+			fmt.Printf("(supervisor) switching to %d baud\n", nextBaudRate)
+			action("setting baud rate", sport.SetBaudRate(nextBaudRate))
+			action("setting read timeout", sport.SetReadTimeout(crossfire.GetRefreshRate(nextBaudRate)*4))
+		}
 	}
 
 	action("stopping recv loop", c.StopRecvLoop())
 	action("stopping send loop", c.StopSendLoop())
 	action("closing serial port", sport.Close())
 
+	if verifying != nil {
+		verifying.result <- errors.New("link was stopped")
+	}
+
+	c.speedChan = nil
 	c.portState = PortUnknown
 	c.supervisorState = SupervisorInactive
 	return nil
-}
+}
\ No newline at end of file
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"fmt"
+	crsf "github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+// PhaseLockSyncTimeout how long without a sync frame before the schedule falls back to a fixed period,
+// ELRS sends one every 200ms
+const PhaseLockSyncTimeout = time.Second
+
+// PhaseLockSyncs how many sync frames in a row have to report an offset within the tolerance for a lock
+const PhaseLockSyncs = 3
+
+// gains of the phase lock, the offset is applied partially to the next send instant, and slowly trims the period
+// to follow the drift between our clock and the module's
+const (
+	phaseGain  = 0.5
+	periodGain = 0.05
+	// errorGain smooths the offsets the lock is decided on, a single late frame does not break a lock
+	errorGain = 0.25
+)
+
+// PhaseLockState tells how the channel frames are scheduled
+type PhaseLockState struct {
+	// Locked the smoothed offset stayed within a tenth of the period for PhaseLockSyncs sync frames in a row
+	Locked bool
+	// Synced sync frames arrive, otherwise the period is the last one the module reported (or derived
+	// from the baud rate, before the first sync frame)
+	Synced bool
+	// Period the interval between channel frames, including the trim
+	Period time.Duration
+	// Error how far the channel frames are from where the module wants them, the offsets of the sync frames smoothed
+	Error time.Duration
+	// LastSync when the last sync frame arrived
+	LastSync time.Time
+}
+
+func (s PhaseLockState) String() string {
+	if !s.Synced {
+		return fmt.Sprintf("unsynced, period %v", s.Period)
+	}
+	lock := "unlocked"
+	if s.Locked {
+		lock = "locked"
+	}
+	return fmt.Sprintf("%s, period %v, error %v", lock, s.Period, s.Error)
+}
+
+// phaseLock schedules the channel frames on the air packets of the module. The module reports its packet period
+// (rate) and how far the frames arrive from the instant it wants them (offset) in OpenTX sync frames.
+// Send instants are kept on a grid of the period, so the phase does not move unless an offset moves it.
+// It is only used by the send loop.
+type phaseLock struct {
+	period time.Duration
+	trim   time.Duration
+	next   time.Time
+
+	synced   bool
+	lastSync time.Time
+	error    time.Duration
+	inTol    int
+	locked   bool
+}
+
+// newPhaseLock starts out with a period derived from the baud rate, until the module reports its packet rate
+func newPhaseLock(fallback time.Duration, now time.Time) *phaseLock {
+	return &phaseLock{
+		period: fallback,
+		next:   now.Add(fallback),
+	}
+}
+
+// tolerance the error a lock allows, a tenth of the period, but not below what the OS timers can hit
+func (p *phaseLock) tolerance() time.Duration {
+	return max(p.period/10, 100*time.Microsecond)
+}
+
+// Sync takes the rate and offset of a sync frame, in units of 0.1 microseconds
+func (p *phaseLock) Sync(rate int32, offset int32, now time.Time) {
+	period := time.Duration(rate) * 100 * time.Nanosecond
+	if period < crsf.MinRefreshRate || period > crsf.MaxRefreshRate {
+		fmt.Printf("(send-loop) ignoring sync frame with period %v\n", period)
+		return
+	}
+	phaseError := time.Duration(offset) * 100 * time.Nanosecond
+
+	if period != p.period {
+		//a new packet rate, the trim of the old one means nothing
+		p.trim = 0
+		p.period = period
+	}
+
+	//the offset is wrapped into half a period either way, moving by a whole period changes nothing
+	phaseError = phaseError % period
+	if phaseError > period/2 {
+		phaseError -= period
+	} else if phaseError < -period/2 {
+		phaseError += period
+	}
+
+	//a positive offset asks for the frames to come later
+	p.next = p.next.Add(time.Duration(float64(phaseError) * phaseGain))
+	if p.synced {
+		//the part of the error left after the last correction is drift, spread over the packets since then
+		if packets := now.Sub(p.lastSync) / p.currentPeriod(); packets > 0 {
+			p.trim += time.Duration(float64(phaseError) * periodGain / float64(packets))
+			p.trim = max(-p.period/10, min(p.trim, p.period/10))
+		}
+	}
+
+	if p.synced {
+		p.error += time.Duration(float64(phaseError-p.error) * errorGain)
+	} else {
+		p.error = phaseError
+	}
+	p.synced = true
+	p.lastSync = now
+
+	if absDuration(p.error) <= p.tolerance() {
+		p.inTol += 1
+	} else {
+		p.inTol = 0
+	}
+
+	wasLocked := p.locked
+	if !p.locked && p.inTol >= PhaseLockSyncs {
+		p.locked = true
+	} else if p.locked && absDuration(p.error) > 2*p.tolerance() {
+		p.locked = false
+	}
+
+	if p.locked != wasLocked {
+		fmt.Printf("(send-loop) phase %s\n", p.State())
+	}
+}
+
+// Next returns when the next channels frame is due, and moves the schedule on past it
+func (p *phaseLock) Next(now time.Time) time.Time {
+	if p.synced && now.Sub(p.lastSync) > PhaseLockSyncTimeout {
+		//keep the last packet period, the module's rate outlives its sync frames
+		p.synced = false
+		p.locked = false
+		p.inTol = 0
+		p.trim = 0
+		fmt.Printf("(send-loop) no sync frame for %v, phase %s\n", PhaseLockSyncTimeout, p.State())
+	}
+
+	period := p.currentPeriod()
+	if p.next.Before(now) {
+		//late (e.g. a slow write), skip the missed slots rather than sending a burst
+		missed := now.Sub(p.next)/period + 1
+		p.next = p.next.Add(missed * period)
+	}
+	due := p.next
+	p.next = p.next.Add(period)
+	return due
+}
+
+func (p *phaseLock) currentPeriod() time.Duration {
+	return max(crsf.MinRefreshRate, min(p.period+p.trim, crsf.MaxRefreshRate))
+}
+
+func (p *phaseLock) State() PhaseLockState {
+	return PhaseLockState{
+		Locked:   p.locked,
+		Synced:   p.synced,
+		Period:   p.currentPeriod(),
+		Error:    p.error,
+		LastSync: p.lastSync,
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// PhaseLock tells how the channel frames are scheduled on the module's packets
+func (c *Controller) PhaseLock() PhaseLockState {
+	c.phaseLockMutex.RLock()
+	defer c.phaseLockMutex.RUnlock()
+	return c.phaseLockState
+}
+
+func (c *Controller) setPhaseLockState(state PhaseLockState) {
+	c.phaseLockMutex.Lock()
+	defer c.phaseLockMutex.Unlock()
+	c.phaseLockState = state
+}
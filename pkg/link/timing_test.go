@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"testing"
+	"time"
+)
+
+// 4ms packets (250Hz), in the units of sync frames (0.1 microseconds)
+const testRate int32 = 40000
+
+var testStart = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPhaseLockOffsetWrapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int32
+		wantError time.Duration
+	}{
+		{"early", 1000, 100 * time.Microsecond},
+		{"late", -1000, -100 * time.Microsecond},
+		{"half a period", 20000, 2 * time.Millisecond},
+		{"more than half a period", 30000, -1 * time.Millisecond},
+		{"less than minus half a period", -30000, 1 * time.Millisecond},
+		{"more than a period", 90000, 1 * time.Millisecond},
+		{"less than minus a period", -50000, -1 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		lock := newPhaseLock(4*time.Millisecond, testStart)
+		lock.Sync(testRate, test.offset, testStart)
+
+		if state := lock.State(); state.Error != test.wantError {
+			t.Errorf("%s: offset %d is an error of %v, want %v", test.name, test.offset, state.Error, test.wantError)
+		}
+		//the first send moves by half the error
+		if due, want := lock.Next(testStart), testStart.Add(4*time.Millisecond+test.wantError/2); !due.Equal(want) {
+			t.Errorf("%s: next send at %v, want %v", test.name, due.Sub(testStart), want.Sub(testStart))
+		}
+	}
+}
+
+func TestPhaseLockHysteresis(t *testing.T) {
+	//offsets of sync frames 200ms apart, the tolerance of 4ms packets is 400us
+	tests := []struct {
+		offset     int32
+		wantLocked bool
+	}{
+		{0, false},
+		{0, false},
+		{0, true},
+		//the smoothed error goes 250us, 437us (outside the tolerance, within twice of it) ...
+		{10000, true},
+		{10000, true},
+		{10000, true},
+		{10000, true},
+		{10000, true},
+		//... and 822us
+		{10000, false},
+		//back down to 616us, 462us, 347us, 260us and 195us, the last 3 within the tolerance
+		{0, false},
+		{0, false},
+		{0, false},
+		{0, false},
+		{0, true},
+	}
+
+	lock := newPhaseLock(4*time.Millisecond, testStart)
+	now := testStart
+	for i, test := range tests {
+		lock.Sync(testRate, test.offset, now)
+		if state := lock.State(); state.Locked != test.wantLocked {
+			t.Fatalf("sync %d (offset %d): locked is %v, want %v, error %v", i, test.offset, state.Locked, test.wantLocked, state.Error)
+		}
+		now = now.Add(200 * time.Millisecond)
+	}
+}
+
+func TestPhaseLockTrim(t *testing.T) {
+	lock := newPhaseLock(4*time.Millisecond, testStart)
+	lock.Sync(testRate, 0, testStart)
+
+	//100us late after 50 packets, a twentieth of it is spread over them
+	lock.Sync(testRate, 1000, testStart.Add(200*time.Millisecond))
+	if period, want := lock.State().Period, 4*time.Millisecond+100*time.Nanosecond; period != want {
+		t.Errorf("period is %v, want %v", period, want)
+	}
+
+	//the trim is limited to a tenth of the period
+	now := testStart.Add(200 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		now = now.Add(8 * time.Millisecond)
+		lock.Sync(testRate, 20000, now)
+	}
+	if period, want := lock.State().Period, 4400*time.Microsecond; period != want {
+		t.Errorf("period is %v, want %v", period, want)
+	}
+
+	//a new packet rate starts without a trim
+	lock.Sync(testRate/2, 0, now.Add(8*time.Millisecond))
+	if period, want := lock.State().Period, 2*time.Millisecond; period != want {
+		t.Errorf("period is %v after a new rate, want %v", period, want)
+	}
+}
+
+func TestPhaseLockIgnoresBadRates(t *testing.T) {
+	for _, rate := range []int32{0, 1000, 600000} {
+		lock := newPhaseLock(4*time.Millisecond, testStart)
+		lock.Sync(rate, 0, testStart)
+
+		if state := lock.State(); state.Synced || state.Period != 4*time.Millisecond {
+			t.Errorf("rate %d: synced is %v, period %v, want the sync frame ignored", rate, state.Synced, state.Period)
+		}
+	}
+}
+
+func TestPhaseLockSyncTimeout(t *testing.T) {
+	//the period follows the baud rate until the module reports 4ms packets
+	lock := newPhaseLock(10*time.Millisecond, testStart)
+	now := testStart
+	for i := 0; i < PhaseLockSyncs; i++ {
+		lock.Sync(testRate, 0, now)
+		now = now.Add(200 * time.Millisecond)
+	}
+	if state := lock.State(); !state.Synced || !state.Locked {
+		t.Fatalf("phase lock is %s, want locked", state)
+	}
+
+	lock.Next(testStart.Add(PhaseLockSyncTimeout))
+	if state := lock.State(); !state.Synced || !state.Locked {
+		t.Errorf("phase lock is %s before the sync timeout, want locked", state)
+	}
+
+	lock.Next(now.Add(PhaseLockSyncTimeout))
+	state := lock.State()
+	if state.Synced || state.Locked {
+		t.Errorf("phase lock is %s after the sync timeout, want unsynced", state)
+	}
+	if state.Period != 4*time.Millisecond {
+		t.Errorf("period is %v after the sync timeout, want the reported 4ms", state.Period)
+	}
+}
+
+func TestPhaseLockSkipsMissedSlots(t *testing.T) {
+	lock := newPhaseLock(4*time.Millisecond, testStart)
+
+	tests := []struct {
+		name string
+		now  time.Duration
+		want time.Duration
+	}{
+		{"first", 0, 4 * time.Millisecond},
+		{"on time", 1 * time.Millisecond, 8 * time.Millisecond},
+		{"late", 21 * time.Millisecond, 24 * time.Millisecond},
+		{"after the late one", 22 * time.Millisecond, 28 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if due := lock.Next(testStart.Add(test.now)); due.Sub(testStart) != test.want {
+			t.Errorf("%s: next send at %v, want %v", test.name, due.Sub(testStart), test.want)
+		}
+	}
+}
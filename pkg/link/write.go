@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package link
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+)
+
+// WriteParameter validates the value against the field's range, writes it, and reads the field back
+// to make sure the device took it. It returns the field as read back. When the device kept a different value,
+// the error is a *ParameterWriteError, and a value out of range is a *settings.ValueRangeError.
+func (c *Controller) WriteParameter(deviceId crossfire.Endpoint, field settings.FieldType, value any) (settings.FieldType, error) {
+	encoded, err := settings.EncodeValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	c.parametersMutex.Lock()
+	defer c.parametersMutex.Unlock()
+
+	fieldId := uint8(field.Id())
+	fmt.Printf("(parameters) writing %x to field %d (%s)\n", encoded, fieldId, field.Name())
+
+	updated, err := c.writeParameterValue(deviceId, fieldId, encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := settings.CurrentValue(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(actual, encoded) {
+		return updated, &ParameterWriteError{DeviceId: deviceId, Field: updated, Written: encoded, Actual: actual}
+	}
+
+	return updated, nil
+}
@@ -3,3 +3,15 @@
 // SPDX-License-Identifier: FS-0.9-or-later
 
 package serial
+
+import "time"
+
+const (
+	TcpScheme = "tcp://"
+	UdpScheme = "udp://"
+)
+
+const DefaultDialTimeout = 2 * time.Second
+
+// MaxDatagramSize is large enough for any UDP datagram the bridge sends
+const MaxDatagramSize = 64 * 1024
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package serial
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/tomb.v2"
+	"net"
+	"sync"
+	"time"
+)
+
+const bridgeReadTimeout = 5 * time.Millisecond
+
+// NetBridge exposes a local Transport on the network, so that a NetPort on another machine
+// can talk to the TX module as if it was attached locally.
+// TCP serves one client at a time (a new client replaces the previous one), UDP replies to
+// whichever peer sent the most recent datagram.
+type NetBridge struct {
+	Port    Transport
+	Network string
+	Address string
+
+	peerMutex      sync.Mutex
+	peerConn       net.Conn
+	peerPacketConn net.PacketConn
+	peerAddr       net.Addr
+
+	//serializes port writes from client goroutines with the port being re-opened
+	portMutex sync.Mutex
+
+	tomb *tomb.Tomb
+}
+
+func (b *NetBridge) Start() error {
+	if b.tomb != nil && b.tomb.Alive() {
+		return errors.New("bridge is already active")
+	}
+
+	if err := b.Port.SetReadTimeout(bridgeReadTimeout); err != nil {
+		return err
+	}
+
+	//listen here rather than in the loops, a bad address or a port in use is reported to the caller
+	var listener net.Listener
+	var conn net.PacketConn
+	var err error
+
+	switch b.Network {
+	case "tcp":
+		if listener, err = net.Listen(b.Network, b.Address); err != nil {
+			return err
+		}
+	case "udp":
+		if conn, err = net.ListenPacket(b.Network, b.Address); err != nil {
+			return err
+		}
+	default:
+		return errors.New(fmt.Sprintf("unsupported network %s", b.Network))
+	}
+
+	fmt.Printf("(bridge) starting, port: %s, listen: %s://%s\n", b.Port.PortName(), b.Network, b.Address)
+
+	b.tomb = &tomb.Tomb{}
+	if listener != nil {
+		b.tomb.Go(func() error {
+			<-b.tomb.Dying()
+			return listener.Close()
+		})
+		b.tomb.Go(func() error {
+			return b.acceptLoop(listener)
+		})
+	} else {
+		b.tomb.Go(func() error {
+			<-b.tomb.Dying()
+			return conn.Close()
+		})
+		b.tomb.Go(func() error {
+			return b.datagramLoop(conn)
+		})
+	}
+	b.tomb.Go(func() error {
+		b.portLoop()
+		fmt.Printf("(bridge) exiting ...\n")
+		return nil
+	})
+
+	return nil
+}
+
+func (b *NetBridge) Stop() error {
+	if b.tomb == nil || !b.tomb.Alive() {
+		return nil
+	}
+
+	b.tomb.Kill(nil)
+
+	//unblocks the read of the client loop
+	b.peerMutex.Lock()
+	if b.peerConn != nil {
+		_ = b.peerConn.Close()
+		b.peerConn = nil
+	}
+	b.peerMutex.Unlock()
+
+	return b.tomb.Wait()
+}
+
+// portLoop copies bytes from the port to the current peer, re-opening the port with backoff when it goes away
+func (b *NetBridge) portLoop() {
+	buf := make([]byte, 1024)
+
+	for b.openWait() {
+		for b.tomb.Alive() {
+			count, err := b.Port.Read(buf)
+			if err != nil {
+				fmt.Printf("(bridge) error reading port %s. %s\n", b.Port.PortName(), err.Error())
+				break
+			}
+
+			if count > 0 {
+				b.sendToPeer(buf[:count])
+			}
+		}
+
+		b.portMutex.Lock()
+		if err := b.Port.Close(); err != nil {
+			fmt.Printf("(bridge) error closing port %s. %s\n", b.Port.PortName(), err.Error())
+		}
+		b.portMutex.Unlock()
+	}
+}
+
+// openWait opens the port with exponential backoff, it returns false if the bridge is stopped first
+func (b *NetBridge) openWait() bool {
+	attempts := 0
+	maxAttempts := 8
+	initialDelay := time.Millisecond * 32
+	currentDelay := initialDelay
+
+	for {
+		if !b.tomb.Alive() {
+			return false
+		}
+
+		b.portMutex.Lock()
+		err := b.Port.Open()
+		b.portMutex.Unlock()
+
+		if err == nil {
+			fmt.Printf("(bridge) port %s opened\n", b.Port.PortName())
+			return true
+		}
+
+		attempts += 1
+		if attempts > maxAttempts {
+			attempts = 0
+			currentDelay = initialDelay
+		}
+
+		fmt.Printf("(bridge) error opening port %s (sleeping %s)\n", b.Port.PortName(), currentDelay)
+		select {
+		case <-b.tomb.Dying():
+			return false
+		case <-time.After(currentDelay):
+		}
+		currentDelay *= 2
+	}
+}
+
+func (b *NetBridge) acceptLoop(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !b.tomb.Alive() {
+				return nil
+			}
+			return err
+		}
+
+		fmt.Printf("(bridge) client %s connected\n", conn.RemoteAddr())
+
+		b.peerMutex.Lock()
+		if !b.tomb.Alive() {
+			//accepted while stopping, after Stop closed the previous client
+			b.peerMutex.Unlock()
+			_ = conn.Close()
+			return nil
+		}
+		if b.peerConn != nil {
+			_ = b.peerConn.Close()
+		}
+		b.peerConn = conn
+		b.peerMutex.Unlock()
+
+		b.tomb.Go(func() error {
+			b.clientLoop(conn)
+			return nil
+		})
+	}
+}
+
+func (b *NetBridge) clientLoop(conn net.Conn) {
+	buf := make([]byte, 1024)
+
+	for {
+		count, err := conn.Read(buf)
+		if err != nil {
+			break
+		}
+		b.writeToPort(buf[:count])
+	}
+
+	fmt.Printf("(bridge) client %s disconnected\n", conn.RemoteAddr())
+
+	b.peerMutex.Lock()
+	if b.peerConn == conn {
+		b.peerConn = nil
+	}
+	b.peerMutex.Unlock()
+	_ = conn.Close()
+}
+
+func (b *NetBridge) datagramLoop(conn net.PacketConn) error {
+	buf := make([]byte, MaxDatagramSize)
+
+	for {
+		count, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !b.tomb.Alive() {
+				return nil
+			}
+			return err
+		}
+
+		b.peerMutex.Lock()
+		if b.peerAddr == nil || b.peerAddr.String() != addr.String() {
+			fmt.Printf("(bridge) peer %s active\n", addr)
+		}
+		b.peerAddr = addr
+		b.peerPacketConn = conn
+		b.peerMutex.Unlock()
+
+		b.writeToPort(buf[:count])
+	}
+}
+
+func (b *NetBridge) writeToPort(data []byte) {
+	b.portMutex.Lock()
+	defer b.portMutex.Unlock()
+
+	if _, err := b.Port.Write(data); err != nil {
+		fmt.Printf("(bridge) error writing port %s. %s\n", b.Port.PortName(), err.Error())
+	}
+}
+
+func (b *NetBridge) sendToPeer(data []byte) {
+	b.peerMutex.Lock()
+	defer b.peerMutex.Unlock()
+
+	if b.peerConn != nil {
+		if _, err := b.peerConn.Write(data); err != nil {
+			fmt.Printf("(bridge) error writing to client %s. %s\n", b.peerConn.RemoteAddr(), err.Error())
+		}
+	} else if b.peerPacketConn != nil && b.peerAddr != nil {
+		if _, err := b.peerPacketConn.WriteTo(data, b.peerAddr); err != nil {
+			fmt.Printf("(bridge) error writing to peer %s. %s\n", b.peerAddr, err.Error())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package serial
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// NetPort carries raw CRSF bytes over a TCP stream (ser2net style) or over UDP datagrams.
+// BaudRate is the rate the remote TX module runs at, it is only used to compute refresh rates.
+type NetPort struct {
+	Network     string `json:"network"`
+	Address     string `json:"address"`
+	BaudRate    int32
+	ReadTimeout time.Duration
+	DialTimeout time.Duration
+
+	conn net.Conn
+
+	//a UDP datagram is read into datagram, pending holds the bytes of it not returned yet
+	datagram []byte
+	pending  []byte
+}
+
+// IsNetworkName checks if a port name refers to a NetPort, i.e. tcp://host:port or udp://host:port
+func IsNetworkName(name string) bool {
+	return strings.HasPrefix(name, TcpScheme) || strings.HasPrefix(name, UdpScheme)
+}
+
+// NewTransport returns a NetPort for tcp:// and udp:// names, and a serial Port for anything else
+func NewTransport(name string, baudRate int32) Transport {
+	if strings.HasPrefix(name, TcpScheme) {
+		return &NetPort{Network: "tcp", Address: strings.TrimPrefix(name, TcpScheme), BaudRate: baudRate}
+	}
+
+	if strings.HasPrefix(name, UdpScheme) {
+		return &NetPort{Network: "udp", Address: strings.TrimPrefix(name, UdpScheme), BaudRate: baudRate}
+	}
+
+	return &Port{Name: name, BaudRate: baudRate}
+}
+
+func (p *NetPort) Open() error {
+	dialTimeout := p.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = DefaultDialTimeout
+	}
+
+	conn, err := net.DialTimeout(p.Network, p.Address, dialTimeout)
+	if err != nil {
+		return err
+	}
+
+	p.conn = conn
+	p.pending = nil
+
+	return nil
+}
+
+func (p *NetPort) SetReadTimeout(duration time.Duration) error {
+	p.ReadTimeout = duration
+	return nil
+}
+
+// SetBaudRate fails for any rate but the current one, the remote end of the connection owns the actual serial port
+// and would stay at the old rate
+func (p *NetPort) SetBaudRate(baudRate int32) error {
+	if baudRate != p.BaudRate {
+		return errors.New(fmt.Sprintf("cannot change the baud rate of network port %s", p.PortName()))
+	}
+	return nil
+}
+
+func (p *NetPort) Write(bytes []byte) (int, error) {
+	if p.conn == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", p.PortName()))
+	}
+	return p.conn.Write(bytes)
+}
+
+// Read behaves like a serial port read, it returns (0, nil) when the read timeout expires
+func (p *NetPort) Read(bytes []byte) (int, error) {
+	if p.conn == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", p.PortName()))
+	}
+
+	if len(p.pending) > 0 {
+		count := copy(bytes, p.pending)
+		p.pending = p.pending[count:]
+		return count, nil
+	}
+
+	if p.ReadTimeout > 0 {
+		if err := p.conn.SetReadDeadline(time.Now().Add(p.ReadTimeout)); err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	var err error
+
+	if p.Network == "udp" {
+		//a datagram must be read in one go, or the rest of it is lost
+		if p.datagram == nil {
+			p.datagram = make([]byte, MaxDatagramSize)
+		}
+		if count, err = p.conn.Read(p.datagram); err == nil {
+			p.pending = p.datagram[:count]
+			count = copy(bytes, p.pending)
+			p.pending = p.pending[count:]
+		}
+	} else {
+		count, err = p.conn.Read(bytes)
+	}
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return count, nil
+	}
+
+	return count, err
+}
+
+func (p *NetPort) Close() error {
+	if p == nil || p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+func (p *NetPort) PortName() string {
+	return p.Network + "://" + p.Address
+}
+
+func (p *NetPort) PortBaudRate() int32 {
+	return p.BaudRate
+}
@@ -5,6 +5,7 @@
 package serial
 
 import (
+	"errors"
 	"fmt"
 	"go.bug.st/serial"
 	"time"
@@ -18,13 +19,17 @@ type Port struct {
 	ReadTimeout time.Duration
 }
 
-func (p *Port) Open() error {
-	port, err := serial.Open(p.Name, &serial.Mode{
+func (p *Port) mode() *serial.Mode {
+	return &serial.Mode{
 		BaudRate: int(p.BaudRate),
 		Parity:   serial.NoParity,
 		DataBits: 8,
 		StopBits: serial.OneStopBit,
-	})
+	}
+}
+
+func (p *Port) Open() error {
+	port, err := serial.Open(p.Name, p.mode())
 
 	if err != nil {
 		return err
@@ -40,15 +45,35 @@ func (p *Port) Open() error {
 }
 
 func (p *Port) SetReadTimeout(duration time.Duration) error {
+	p.ReadTimeout = duration
+	if p.port == nil || *p.port == nil {
+		//applied on the next Open
+		return nil
+	}
 	return (*p.port).SetReadTimeout(duration)
 }
 
-func (p *Port) Write(bytes []byte) (int32, error) {
+func (p *Port) SetBaudRate(baudRate int32) error {
+	p.BaudRate = baudRate
+	if p.port == nil || *p.port == nil {
+		//applied on the next Open
+		return nil
+	}
+	return (*p.port).SetMode(p.mode())
+}
+
+func (p *Port) Write(bytes []byte) (int, error) {
+	if p.port == nil || *p.port == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", p.Name))
+	}
 	count, err := (*p.port).Write(bytes)
-	return int32(count), err
+	return count, err
 }
 
 func (p *Port) Read(bytes []byte) (int, error) {
+	if p.port == nil || *p.port == nil {
+		return 0, errors.New(fmt.Sprintf("port %s is not open", p.Name))
+	}
 	count, err := (*p.port).Read(bytes)
 	return count, err
 }
@@ -57,7 +82,17 @@ func (p *Port) Close() error {
 	if p == nil || p.port == nil || *p.port == nil {
 		return nil
 	}
-	return (*p.port).Close()
+	err := (*p.port).Close()
+	p.port = nil
+	return err
+}
+
+func (p *Port) PortName() string {
+	return p.Name
+}
+
+func (p *Port) PortBaudRate() int32 {
+	return p.BaudRate
 }
 
 func (p *Port) OpenWait() {
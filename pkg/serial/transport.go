@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package serial
+
+import (
+	"time"
+)
+
+// Transport is a byte stream that carries CRSF frames to and from a TX module.
+// Port implements it for local serial devices, other implementations can carry
+// the same bytes over pipes, PTYs, sockets or recorded captures.
+type Transport interface {
+	Open() error
+	Close() error
+
+	// Read returns (0, nil) when no data arrived before the read timeout expired
+	Read(bytes []byte) (int, error)
+	Write(bytes []byte) (int, error)
+	SetReadTimeout(duration time.Duration) error
+	SetBaudRate(baudRate int32) error
+
+	PortName() string
+	PortBaudRate() int32
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"strings"
+)
+
+// cmsItems the entries of the simulated CMS main menu
+var cmsItems = []string{"PROFILE", "VTX", "MISC", "SAVE EXIT", "EXIT"}
+
+// 11-bit stick positions beyond which a stick counts as moved (1700us and 1300us)
+const (
+	cmsStickHigh util.CRSFValue = 1312
+	cmsStickLow  util.CRSFValue = 672
+)
+
+// cmsState is only used by the read loop
+type cmsState struct {
+	open     bool
+	rows     int
+	cols     int
+	cursor   int
+	selected string
+	lastKey  string
+}
+
+// handleDisplayPort answers the display port commands of the radio, like a Betaflight flight controller
+func (m *Module) handleDisplayPort(f *crossfire.DisplayPort) {
+	if f.Dst != crossfire.FlightControllerEndpoint {
+		return
+	}
+
+	cms := &m.cms
+	switch f.SubCommand {
+	case crossfire.DisplayPortOpen:
+		fmt.Printf("(simulator) cms opened, %dx%d\n", f.Rows, f.Cols)
+		*cms = cmsState{open: true, rows: int(f.Rows), cols: int(f.Cols)}
+		m.drawCMS()
+	case crossfire.DisplayPortPoll:
+		if cms.open {
+			m.drawCMS()
+		}
+	case crossfire.DisplayPortClose:
+		fmt.Printf("(simulator) cms closed\n")
+		cms.open = false
+		m.write(createDisplayPortFrame(&crossfire.DisplayPort{SubCommand: crossfire.DisplayPortClear}))
+	}
+}
+
+// navigateCMS moves through the menu when the sticks move out of the center, like Betaflight's stick commands
+func (m *Module) navigateCMS(channels [16]util.CRSFValue) {
+	cms := &m.cms
+	if !cms.open {
+		return
+	}
+
+	//AETR channel order
+	roll, pitch, yaw := channels[0], channels[1], channels[3]
+	key := ""
+	switch {
+	case pitch > cmsStickHigh:
+		key = "up"
+	case pitch < cmsStickLow:
+		key = "down"
+	case roll < cmsStickLow:
+		key = "left"
+	case roll > cmsStickHigh:
+		key = "right"
+	case yaw < cmsStickLow:
+		key = "esc"
+	}
+
+	if key == cms.lastKey {
+		return
+	}
+	cms.lastKey = key
+
+	switch key {
+	case "up":
+		cms.cursor = (cms.cursor + len(cmsItems) - 1) % len(cmsItems)
+	case "down":
+		cms.cursor = (cms.cursor + 1) % len(cmsItems)
+	case "right":
+		cms.selected = cmsItems[cms.cursor]
+	case "left", "esc":
+		cms.selected = ""
+	default:
+		return
+	}
+
+	fmt.Printf("(simulator) cms key %s\n", key)
+	m.drawCMS()
+}
+
+func (m *Module) drawCMS() {
+	cms := &m.cms
+
+	lines := []string{"-- MAIN --"}
+	for i, item := range cmsItems {
+		prefix := "  "
+		if i == cms.cursor {
+			prefix = "> "
+		}
+		lines = append(lines, prefix+item)
+	}
+	if cms.selected != "" {
+		lines = append(lines, "", "SELECTED "+cms.selected)
+	}
+
+	m.write(createDisplayPortFrame(&crossfire.DisplayPort{SubCommand: crossfire.DisplayPortClear}))
+	for row := 0; row < cms.rows && row < len(lines); row++ {
+		line := lines[row]
+		if len(line) < cms.cols {
+			line += strings.Repeat(" ", cms.cols-len(line))
+		}
+		m.write(createDisplayPortFrame(&crossfire.DisplayPort{
+			SubCommand: crossfire.DisplayPortUpdate,
+			Row:        uint8(row),
+			Text:       []byte(line[:cms.cols]),
+		}))
+	}
+}
+
+func createDisplayPortFrame(frame *crossfire.DisplayPort) []byte {
+	frame.Addr = crossfire.HandsetEndpoint
+	frame.Dst = crossfire.HandsetEndpoint
+	frame.Src = crossfire.FlightControllerEndpoint
+	return frame.Marshal()
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+// MaxChunkSize is the largest parameter entry payload that fits into a single frame
+// (64 - address, length, type, dst, src, field id, chunks remaining, crc)
+const MaxChunkSize = crossfire.MaxFrameSize - 8
+
+func createLinkStatsFrame(stats LinkStats) []byte {
+	frame := crossfire.LinkStats{
+		Addr:          crossfire.HandsetEndpoint,
+		UplinkRSSI1:   stats.UplinkRSSI1,
+		UplinkRSSI2:   stats.UplinkRSSI2,
+		UplinkLQ:      stats.UplinkLQ,
+		UplinkSNR:     stats.UplinkSNR,
+		ActiveAntenna: stats.ActiveAntenna,
+		RFMode:        stats.RFMode,
+		UplinkPower:   stats.UplinkPower,
+		DownlinkRSSI:  stats.DownlinkRSSI,
+		DownlinkLQ:    stats.DownlinkLQ,
+		DownlinkSNR:   stats.DownlinkSNR,
+	}
+	return frame.Marshal()
+}
+
+// createSyncFrame builds an OpenTX sync frame, rate and offset are in units of 0.1 microseconds
+func createSyncFrame(rate int32, offset int32) []byte {
+	frame := crossfire.OpenTxSync{
+		Addr:   crossfire.HandsetEndpoint,
+		Dst:    crossfire.HandsetEndpoint,
+		Src:    crossfire.ModuleEndpoint,
+		Rate:   rate,
+		Offset: offset,
+	}
+	return frame.Marshal()
+}
+
+// syncSafeMargin how long before an air packet the channels should arrive, like ELRS keeps some headroom
+const syncSafeMargin = 100 * time.Microsecond
+
+// syncOffset tells the handset how much later its channels frames should come, from how long before an air packet
+// the last one arrived. Like ELRS, it is wrapped into half a packet period either way.
+func syncOffset(sinceChannels time.Duration, packetRate time.Duration) time.Duration {
+	offset := (sinceChannels - syncSafeMargin) % packetRate
+	if offset > packetRate/2 {
+		offset -= packetRate
+	}
+	return offset
+}
+
+func createDeviceInfoFrame(dst crossfire.Endpoint, info DeviceInfo, fieldCount uint8) []byte {
+	version := func(v [3]uint8) uint32 {
+		return uint32(v[0])<<16 | uint32(v[1])<<8 | uint32(v[2])
+	}
+
+	frame := crossfire.DeviceInfo{
+		Addr:            crossfire.HandsetEndpoint,
+		Dst:             dst,
+		Src:             crossfire.ModuleEndpoint,
+		Name:            info.Name,
+		SerialNumber:    info.SerialNumber,
+		HardwareVersion: version(info.HardwareVersion),
+		SoftwareVersion: version(info.SoftwareVersion),
+		FieldCount:      fieldCount,
+	}
+	return frame.Marshal()
+}
+
+func createStatusFrame(bad uint8, good uint16, flags uint8, message string) []byte {
+	frame := crossfire.Status{
+		Addr:        crossfire.HandsetEndpoint,
+		Dst:         crossfire.HandsetEndpoint,
+		Src:         crossfire.ModuleEndpoint,
+		BadPackets:  bad,
+		GoodPackets: good,
+		Flags:       flags,
+		Message:     message,
+	}
+	return frame.Marshal()
+}
+
+func createParameterEntryFrame(dst crossfire.Endpoint, fieldId uint8, chunksRemaining uint8, chunk []byte) []byte {
+	frame := crossfire.ParameterEntry{
+		Addr:            crossfire.HandsetEndpoint,
+		Dst:             dst,
+		Src:             crossfire.ModuleEndpoint,
+		FieldId:         fieldId,
+		ChunksRemaining: chunksRemaining,
+		Chunk:           chunk,
+	}
+	return frame.Marshal()
+}
+
+func createSpeedResponseFrame(portId uint8, accepted bool) []byte {
+	return crossfire.NewSpeedResponseCommand(portId, accepted).Marshal()
+}
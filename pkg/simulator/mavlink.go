@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+// handleMavlink sends the MAVLink bytes back, if the simulator is configured to echo them
+func (m *Module) handleMavlink(envelope *crossfire.MavlinkEnvelope) {
+	data, err := m.mavlinkAssembler.Push(envelope)
+	if err != nil {
+		fmt.Printf("(simulator) %s\n", err.Error())
+		return
+	}
+	if data == nil || !m.Config.MavlinkEcho {
+		return
+	}
+
+	fmt.Printf("(simulator) echoing %d mavlink bytes\n", len(data))
+	for _, e := range crossfire.NewMavlinkEnvelopes(crossfire.HandsetEndpoint, data) {
+		m.write(e.Marshal())
+	}
+}
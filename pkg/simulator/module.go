@@ -0,0 +1,533 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crc"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"github.com/kaack/elrs-joystick-control/pkg/util"
+	"golang.org/x/exp/slices"
+	"gopkg.in/tomb.v2"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const slaveClosedRetryDelay = 50 * time.Millisecond
+
+type DeviceInfo struct {
+	Name            string
+	SerialNumber    uint32
+	HardwareVersion [3]uint8
+	SoftwareVersion [3]uint8
+}
+
+type LinkStats struct {
+	UplinkRSSI1   int8
+	UplinkRSSI2   int8
+	UplinkLQ      uint8
+	UplinkSNR     int8
+	ActiveAntenna uint8
+	RFMode        uint8
+	UplinkPower   uint8
+	DownlinkRSSI  int8
+	DownlinkLQ    uint8
+	DownlinkSNR   int8
+}
+
+type Config struct {
+	Device     DeviceInfo
+	Parameters []*Parameter
+	LinkStats  LinkStats
+
+	// PacketRate is the air packet period reported in the OpenTX sync frames
+	PacketRate time.Duration
+
+	// SyncInterval is how often the OpenTX sync frames are sent, 0 disables them
+	SyncInterval      time.Duration
+	LinkStatsInterval time.Duration
+	StatusInterval    time.Duration
+
+	// SensorInterval is how often the sensor frames (rpm, temperature, cells, airspeed, heartbeat, gps time and extended gps) are sent, 0 disables them
+	SensorInterval time.Duration
+
+	// PassthroughInterval is how often the ArduPilot passthrough telemetry is sent, 0 disables it
+	PassthroughInterval time.Duration
+
+	// CraftName is answered to MSP name requests of the simulated flight controller
+	CraftName string
+
+	// MavlinkEcho sends the MAVLink bytes received in envelope frames back
+	MavlinkEcho bool
+
+	// ReceiverModelId is the model id the simulated receiver was bound with, -1 accepts every model
+	ReceiverModelId int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Device: DeviceInfo{
+			Name:            "ELRS Simulator",
+			SerialNumber:    0x454C5253, // "ELRS"
+			HardwareVersion: [3]uint8{1, 0, 0},
+			SoftwareVersion: [3]uint8{3, 4, 0},
+		},
+		Parameters: DefaultParameters(),
+		LinkStats: LinkStats{
+			UplinkRSSI1:  -40,
+			UplinkRSSI2:  -42,
+			UplinkLQ:     100,
+			UplinkSNR:    10,
+			RFMode:       7,
+			UplinkPower:  2,
+			DownlinkRSSI: -45,
+			DownlinkLQ:   100,
+			DownlinkSNR:  8,
+		},
+		PacketRate:        4 * time.Millisecond,
+		SyncInterval:      200 * time.Millisecond,
+		LinkStatsInterval: 200 * time.Millisecond,
+		StatusInterval:    1 * time.Second,
+		CraftName:         "SIM",
+		ReceiverModelId:   -1,
+	}
+}
+
+// Module simulates an ELRS TX module on the other end of a serial line.
+// It answers pings and parameter reads/writes, and emits sync, link-stats and status frames.
+type Module struct {
+	Port   io.ReadWriter
+	Config Config
+
+	stateMutex        sync.RWMutex
+	channels          [16]util.CRSFValue
+	modelId           uint8
+	goodChannelFrames uint64
+	badChannelFrames  uint64
+	badFrames         uint64
+	// lastChannelsTime when the last channels frame arrived, the offset of the sync frames is measured from it
+	lastChannelsTime time.Time
+
+	//only used by the read loop
+	mspAssembler crossfire.MSPAssembler
+	mspSeq       uint8
+
+	mavlinkAssembler crossfire.MavlinkAssembler
+	cms              cmsState
+
+	//only used by the run loop
+	passthrough passthroughState
+
+	writeMutex sync.Mutex
+
+	tomb *tomb.Tomb
+}
+
+func NewModule(port io.ReadWriter, config Config) *Module {
+	return &Module{
+		Port:   port,
+		Config: config,
+	}
+}
+
+func (m *Module) Start() error {
+	if m.tomb != nil && m.tomb.Alive() {
+		return errors.New("simulator is already active")
+	}
+
+	m.tomb = &tomb.Tomb{}
+	m.tomb.Go(m.Run)
+
+	return nil
+}
+
+// Stop stops the simulator, closing the port (if it can be closed) to unblock pending reads
+func (m *Module) Stop() error {
+	if m.tomb == nil || !m.tomb.Alive() {
+		return nil
+	}
+
+	m.tomb.Kill(nil)
+	if closer, ok := m.Port.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	return m.tomb.Wait()
+}
+
+func (m *Module) Channels() [16]util.CRSFValue {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	return m.channels
+}
+
+func (m *Module) ModelId() uint8 {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	return m.modelId
+}
+
+// ChannelFrameCounts returns the number of valid and invalid channel frames received
+func (m *Module) ChannelFrameCounts() (good uint64, bad uint64) {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	return m.goodChannelFrames, m.badChannelFrames
+}
+
+func (m *Module) Run() error {
+	fmt.Printf("(simulator) starting, device: %s\n", m.Config.Device.Name)
+
+	m.tomb.Go(m.readLoop)
+
+	linkStatsTicker := time.NewTicker(m.Config.LinkStatsInterval)
+	statusTicker := time.NewTicker(m.Config.StatusInterval)
+	defer linkStatsTicker.Stop()
+	defer statusTicker.Stop()
+
+	//the air packets are only simulated for their timing
+	airTicker := time.NewTicker(m.Config.PacketRate)
+	defer airTicker.Stop()
+
+	var syncChan <-chan time.Time
+	if m.Config.SyncInterval > 0 {
+		syncTicker := time.NewTicker(m.Config.SyncInterval)
+		defer syncTicker.Stop()
+		syncChan = syncTicker.C
+	}
+
+	var sensorChan <-chan time.Time
+	if m.Config.SensorInterval > 0 {
+		sensorTicker := time.NewTicker(m.Config.SensorInterval)
+		defer sensorTicker.Stop()
+		sensorChan = sensorTicker.C
+	}
+
+	var passthroughChan <-chan time.Time
+	if m.Config.PassthroughInterval > 0 {
+		passthroughTicker := time.NewTicker(m.Config.PassthroughInterval)
+		defer passthroughTicker.Stop()
+		passthroughChan = passthroughTicker.C
+	}
+
+	//rate and offset are sent in units of 0.1 microseconds
+	rate := int32(m.Config.PacketRate / (100 * time.Nanosecond))
+	var offset time.Duration
+	measured := false
+
+Loop:
+	for {
+		select {
+		case <-m.tomb.Dying():
+			break Loop
+		case now := <-airTicker.C:
+			m.stateMutex.RLock()
+			lastChannels := m.lastChannelsTime
+			m.stateMutex.RUnlock()
+			if !lastChannels.IsZero() {
+				offset = syncOffset(now.Sub(lastChannels), m.Config.PacketRate)
+				measured = true
+			}
+		case <-syncChan:
+			//like ELRS, there is nothing to sync to before the handset sends channels
+			if measured {
+				m.write(createSyncFrame(rate, int32(offset/(100*time.Nanosecond))))
+			}
+		case <-linkStatsTicker.C:
+			m.write(createLinkStatsFrame(m.Config.LinkStats))
+		case <-statusTicker.C:
+			good, bad := m.ChannelFrameCounts()
+			flags := uint8(1 << telemetry.StatusConnected)
+			if m.Config.ReceiverModelId >= 0 && m.Config.ReceiverModelId != int(m.ModelId()) {
+				//ELRS sets the model match flag on a mismatch
+				flags |= 1 << telemetry.StatusModelMatch
+			}
+			m.write(createStatusFrame(uint8(min(bad, 0xFF)), uint16(min(good, 0xFFFF)), flags, ""))
+		case now := <-sensorChan:
+			for _, frame := range createSensorFrames(now) {
+				m.write(frame)
+			}
+		case <-passthroughChan:
+			for _, frame := range m.createPassthroughFrames() {
+				m.write(frame)
+			}
+		}
+	}
+
+	fmt.Printf("(simulator) exiting ...\n")
+	return nil
+}
+
+func (m *Module) write(frame []byte) {
+	m.writeMutex.Lock()
+	defer m.writeMutex.Unlock()
+
+	if _, err := m.Port.Write(frame); err != nil && !errors.Is(err, syscall.EIO) {
+		fmt.Printf("(simulator) error writing frame %x. %s\n", frame, err.Error())
+	}
+}
+
+func (m *Module) readLoop() error {
+	buf := make([]byte, 0, 1024)
+	chunk := make([]byte, 256)
+
+	for {
+		count, err := m.Port.Read(chunk)
+		if err != nil {
+			if !m.tomb.Alive() {
+				return nil
+			}
+			if errors.Is(err, syscall.EIO) {
+				//the pseudo-terminal's slave side is closed, wait for it to be re-opened
+				select {
+				case <-m.tomb.Dying():
+				case <-time.After(slaveClosedRetryDelay):
+				}
+				continue
+			}
+			return err
+		}
+
+		buf = append(buf, chunk[:count]...)
+
+		for {
+			skip, frame := m.split(buf)
+			buf = buf[skip:]
+			if frame != nil {
+				m.handleFrame(frame)
+				continue
+			}
+			if skip == 0 {
+				break
+			}
+		}
+
+		//compact the buffer, so it does not keep growing
+		buf = append(make([]byte, 0, 1024), buf...)
+	}
+}
+
+// split looks for a frame sent by the handset, it returns how many bytes to skip and the frame if one was found
+func (m *Module) split(data []byte) (int, []byte) {
+	frameStart := -1
+	for i, c := range data {
+		if c == uint8(crossfire.UartSyncFrame) || c == uint8(crossfire.ModuleEndpoint) {
+			frameStart = i
+			break
+		}
+	}
+
+	if frameStart < 0 {
+		return len(data), nil
+	}
+
+	if frameStart+1 >= len(data) {
+		return frameStart, nil
+	}
+
+	frameLength := int(data[frameStart+1])
+	if frameLength < 2 || frameLength > crossfire.MaxFrameSize-2 {
+		return frameStart + 1, nil
+	}
+
+	if frameStart+frameLength+2 > len(data) {
+		//need more data
+		return frameStart, nil
+	}
+
+	frame := data[frameStart : frameStart+frameLength+2]
+	if crc.D5(frame[2:len(frame)-1]) != frame[len(frame)-1] {
+		m.stateMutex.Lock()
+		m.badFrames += 1
+		if fType := crossfire.FrameType(frame[2]); fType == crossfire.ChannelsFrame || fType == crossfire.SubsetChannelsFrame {
+			m.badChannelFrames += 1
+		}
+		m.stateMutex.Unlock()
+		return frameStart + 1, nil
+	}
+
+	return frameStart + len(frame), frame
+}
+
+func (m *Module) handleFrame(data []byte) {
+	frame, err := crossfire.UnmarshalFrame(data)
+	if err != nil {
+		if fType := crossfire.FrameType(data[2]); fType == crossfire.ChannelsFrame || fType == crossfire.SubsetChannelsFrame {
+			m.stateMutex.Lock()
+			m.badChannelFrames += 1
+			m.stateMutex.Unlock()
+		}
+		return
+	}
+
+	switch f := frame.(type) {
+	case *crossfire.Channels:
+		m.stateMutex.Lock()
+		if f.Addr == crossfire.ModuleEndpoint {
+			m.channels = f.Values
+			m.goodChannelFrames += 1
+			m.lastChannelsTime = time.Now()
+		} else {
+			m.badChannelFrames += 1
+		}
+		channels := m.channels
+		m.stateMutex.Unlock()
+		m.navigateCMS(channels)
+
+	case *crossfire.SubsetChannels:
+		//channels are kept at 11 bits, like the classic channels frame
+		m.stateMutex.Lock()
+		if f.Addr == crossfire.ModuleEndpoint {
+			for i, value := range f.Values {
+				if channel := int(f.StartChannel) + i; channel < len(m.channels) {
+					m.channels[channel] = crossfire.ScaleChannel(value, f.Resolution, crossfire.Resolution11Bit)
+				}
+			}
+			m.goodChannelFrames += 1
+			m.lastChannelsTime = time.Now()
+		} else {
+			m.badChannelFrames += 1
+		}
+		channels := m.channels
+		m.stateMutex.Unlock()
+		m.navigateCMS(channels)
+
+	case *crossfire.PingDevices:
+		if f.Dst == crossfire.AllEndpoint || f.Dst == crossfire.ModuleEndpoint {
+			fmt.Printf("(simulator) ping from %x\n", f.Src)
+			m.write(createDeviceInfoFrame(f.Src, m.Config.Device, uint8(len(m.Config.Parameters))))
+		}
+
+	case *crossfire.ParameterRead:
+		if f.Dst != crossfire.ModuleEndpoint {
+			return
+		}
+		m.sendParameterChunk(f.Src, f.FieldId, f.ChunkIndex)
+
+	case *crossfire.ParameterWrite:
+		if f.Dst != crossfire.ModuleEndpoint {
+			return
+		}
+		m.writeParameter(f.FieldId, f.Value)
+		m.sendParameterChunk(f.Src, f.FieldId, 0)
+
+	case *crossfire.MSP:
+		m.handleMSP(f)
+
+	case *crossfire.MavlinkEnvelope:
+		m.handleMavlink(f)
+
+	case *crossfire.DisplayPort:
+		m.handleDisplayPort(f)
+
+	case *crossfire.Command:
+		if f.SubCommand == crossfire.GeneralSubcommandFrame && f.Command == crossfire.CmdSpeedProposalFrame && len(f.Payload) >= 5 {
+			//a pseudo-terminal has no baud rate, so every supported rate is accepted
+			baudRate := int32(binary.BigEndian.Uint32(f.Payload[1:5]))
+			fmt.Printf("(simulator) speed proposal %d baud\n", baudRate)
+			m.write(createSpeedResponseFrame(f.Payload[0], slices.Contains(crossfire.GetBaudRates(), baudRate)))
+		} else if f.SubCommand == crossfire.SubcommandFrame && f.Command == crossfire.CmdModelSelectFrame && len(f.Payload) >= 1 {
+			m.stateMutex.Lock()
+			if m.modelId != f.Payload[0] {
+				fmt.Printf("(simulator) model id %d selected\n", f.Payload[0])
+			}
+			m.modelId = f.Payload[0]
+			m.stateMutex.Unlock()
+		}
+	}
+}
+
+func (m *Module) findParameter(fieldId uint8) *Parameter {
+	if fieldId == 0 {
+		//field 0 is the root folder
+		return &Parameter{Id: 0, Type: telemetry.CrsfFolder, Name: m.Config.Device.Name}
+	}
+
+	for _, param := range m.Config.Parameters {
+		if param.Id == fieldId {
+			return param
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) sendParameterChunk(dst crossfire.Endpoint, fieldId uint8, chunkIndex uint8) {
+	m.stateMutex.RLock()
+	param := m.findParameter(fieldId)
+	if param == nil {
+		m.stateMutex.RUnlock()
+		fmt.Printf("(simulator) read of unknown parameter %d\n", fieldId)
+		return
+	}
+	chunks := param.Chunks()
+	m.stateMutex.RUnlock()
+
+	if int(chunkIndex) >= len(chunks) {
+		return
+	}
+
+	m.write(createParameterEntryFrame(dst, fieldId, uint8(len(chunks)-int(chunkIndex)-1), chunks[chunkIndex]))
+}
+
+func (m *Module) writeParameter(fieldId uint8, value []byte) {
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+
+	param := m.findParameter(fieldId)
+	if param == nil || len(value) == 0 {
+		return
+	}
+
+	switch param.Type {
+	case telemetry.CrsfCommand:
+		switch settings.CommandStep(value[0]) {
+		case settings.StepClick:
+			if param.Confirm != "" {
+				param.Step = settings.StepAskConfirm
+				param.Info = param.Confirm
+			} else {
+				param.Step = settings.StepExecuting
+				param.Info = "Executing..."
+			}
+		case settings.StepConfirmed:
+			param.Step = settings.StepExecuting
+			param.Info = "Executing..."
+		case settings.StepCancel:
+			param.Step = settings.StepIdle
+			param.Info = ""
+		case settings.StepQuery:
+			//commands complete on the first query
+			param.Step = settings.StepIdle
+			param.Info = ""
+		}
+	case telemetry.CrsfTextSelection, telemetry.CrsfUint8:
+		if value[0] >= param.Min && value[0] <= param.Max {
+			param.Value = value[0]
+		}
+	case telemetry.CrsfInt8:
+		//Min and Max hold the bytes of the signed range
+		if v := int8(value[0]); v >= int8(param.Min) && v <= int8(param.Max) {
+			param.Value = value[0]
+		}
+	case telemetry.CrsfFloat:
+		if len(value) >= 4 {
+			if v := int32(binary.BigEndian.Uint32(value)); v >= param.RawMin && v <= param.RawMax {
+				param.RawValue = v
+			}
+		}
+	case telemetry.CrsfString:
+		if end := bytes.IndexByte(value, 0); end >= 0 {
+			param.Info = string(value[:end])
+		}
+	}
+
+	fmt.Printf("(simulator) parameter %d (%s) written\n", fieldId, param.Name)
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"fmt"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"strings"
+)
+
+// MSP commands the simulated flight controller answers, others are answered with an error
+const (
+	MspApiVersion = 1
+	MspFcVariant  = 2
+	MspFcVersion  = 3
+	MspName       = 10
+	MspBoxNames   = 116
+)
+
+// mspAnswer returns the payload of the answer to a request, false if the command is not supported
+func (m *Module) mspAnswer(request *crossfire.MSPPacket) ([]byte, bool) {
+	switch request.Command {
+	case MspApiVersion:
+		return []byte{0, 1, 46}, true
+	case MspFcVariant:
+		return []byte("BTFL"), true
+	case MspFcVersion:
+		return []byte{4, 5, 0}, true
+	case MspName:
+		return []byte(m.Config.CraftName), true
+	case MspBoxNames:
+		//long enough to need several frames
+		names := []string{"ARM", "ANGLE", "HORIZON", "HEADFREE", "FAILSAFE", "BEEPER", "OSD DISABLE", "BLACKBOX", "AIRMODE", "FLIP OVER AFTER CRASH"}
+		return []byte(strings.Join(names, ";") + ";"), true
+	default:
+		return nil, false
+	}
+}
+
+func (m *Module) handleMSP(frame *crossfire.MSP) {
+	if frame.FrameType != crossfire.MspRequestFrame && frame.FrameType != crossfire.MspWriteFrame {
+		return
+	}
+	if frame.Dst != crossfire.FlightControllerEndpoint {
+		return
+	}
+
+	request, err := m.mspAssembler.Push(frame)
+	if err != nil {
+		fmt.Printf("(simulator) %s\n", err.Error())
+		return
+	}
+	if request == nil {
+		return
+	}
+
+	if frame.FrameType == crossfire.MspWriteFrame {
+		//writes are not answered
+		return
+	}
+
+	payload, ok := m.mspAnswer(request)
+	response := crossfire.MSPPacket{Version: request.Version, Command: request.Command, Payload: payload, Error: !ok}
+	frames, nextSeq, err := response.Fragment(crossfire.MspResponseFrame, crossfire.HandsetEndpoint, frame.Src,
+		crossfire.FlightControllerEndpoint, m.mspSeq, crossfire.MSPResponseChunkSize)
+	if err != nil {
+		fmt.Printf("(simulator) cannot answer msp command %d. %s\n", request.Command, err.Error())
+		return
+	}
+	m.mspSeq = nextSeq
+
+	fmt.Printf("(simulator) answering msp command %d\n", request.Command)
+	for _, f := range frames {
+		m.write(f.Marshal())
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"encoding/binary"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/settings"
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire/telemetry"
+	"strings"
+)
+
+// Parameter is a single entry of the simulated module's parameter tree.
+// Only the members that are relevant to the parameter's Type are used.
+type Parameter struct {
+	Id       uint8
+	ParentId uint8
+	Type     telemetry.CRSFFieldType
+	Name     string
+	Hidden   bool
+
+	//uint8, int8 (as the bytes of its two's complement), text-select
+	Options []string
+	Value   uint8
+	Min     uint8
+	Max     uint8
+	Default uint8
+	Units   string
+
+	//float, the raw values are scaled by 10^Precision
+	RawValue   int32
+	RawMin     int32
+	RawMax     int32
+	RawDefault int32
+	RawStep    int32
+	Precision  uint8
+
+	//info, string, command message
+	Info string
+
+	//command
+	Step    settings.CommandStep
+	Timeout uint8
+	Confirm string //asks for confirmation with this message before executing
+}
+
+// Encode returns the parameter's entry payload, starting with the parent id and data type
+func (p *Parameter) Encode() []byte {
+	dataType := p.Type
+	if p.Hidden {
+		dataType |= settings.HiddenFieldFlag
+	}
+
+	data := []byte{p.ParentId, uint8(dataType)}
+	data = append(data, p.Name...)
+	data = append(data, 0)
+
+	switch p.Type {
+	case telemetry.CrsfTextSelection:
+		data = append(data, strings.Join(p.Options, ";")...)
+		data = append(data, 0)
+		data = append(data, p.Value, p.Min, p.Max, p.Default)
+		data = append(data, p.Units...)
+		data = append(data, 0)
+	case telemetry.CrsfUint8, telemetry.CrsfInt8:
+		data = append(data, p.Value, p.Min, p.Max, p.Default)
+		data = append(data, p.Units...)
+		data = append(data, 0)
+	case telemetry.CrsfFloat:
+		for _, v := range []int32{p.RawValue, p.RawMin, p.RawMax, p.RawDefault} {
+			data = binary.BigEndian.AppendUint32(data, uint32(v))
+		}
+		data = append(data, p.Precision)
+		data = binary.BigEndian.AppendUint32(data, uint32(p.RawStep))
+		data = append(data, p.Units...)
+		data = append(data, 0)
+	case telemetry.CrsfInfo, telemetry.CrsfString:
+		data = append(data, p.Info...)
+		data = append(data, 0)
+	case telemetry.CrsfCommand:
+		data = append(data, uint8(p.Step), p.Timeout)
+		data = append(data, p.Info...)
+		data = append(data, 0)
+	case telemetry.CrsfFolder:
+		//only the name
+	}
+
+	return data
+}
+
+// Chunks splits the encoded parameter into the payloads of consecutive parameter entry frames
+func (p *Parameter) Chunks() [][]byte {
+	data := p.Encode()
+
+	var chunks [][]byte
+	for len(data) > MaxChunkSize {
+		chunks = append(chunks, data[:MaxChunkSize])
+		data = data[MaxChunkSize:]
+	}
+	return append(chunks, data)
+}
+
+// DefaultParameters returns a small parameter tree resembling the one of an ELRS TX module
+func DefaultParameters() []*Parameter {
+	return []*Parameter{
+		{Id: 1, ParentId: 0, Type: telemetry.CrsfTextSelection, Name: "Packet Rate",
+			Options: []string{"50Hz(-115dBm)", "100Hz Full(-112dBm)", "150Hz(-112dBm)", "250Hz(-108dBm)", "500Hz(-105dBm)"},
+			Value:   3, Min: 0, Max: 4, Default: 3},
+		{Id: 2, ParentId: 0, Type: telemetry.CrsfTextSelection, Name: "Telem Ratio",
+			Options: []string{"Std", "Off", "1:128", "1:64", "1:32", "1:16", "1:8", "1:4", "1:2", "Race"},
+			Value:   0, Min: 0, Max: 9, Default: 0},
+		{Id: 3, ParentId: 0, Type: telemetry.CrsfFolder, Name: "TX Power"},
+		{Id: 4, ParentId: 3, Type: telemetry.CrsfTextSelection, Name: "Max Power",
+			Options: []string{"10", "25", "50", "100", "250"},
+			Value:   1, Min: 0, Max: 4, Default: 1, Units: "mW"},
+		{Id: 5, ParentId: 3, Type: telemetry.CrsfTextSelection, Name: "Dynamic",
+			Options: []string{"Off", "Dyn", "AUX9", "AUX10", "AUX11", "AUX12"},
+			Value:   0, Min: 0, Max: 5, Default: 0},
+		{Id: 6, ParentId: 0, Type: telemetry.CrsfUint8, Name: "Model Id", Value: 0, Min: 0, Max: 63, Default: 0},
+		{Id: 7, ParentId: 0, Type: telemetry.CrsfCommand, Name: "Bind", Step: settings.StepIdle, Timeout: 200},
+		{Id: 8, ParentId: 0, Type: telemetry.CrsfInfo, Name: "Sim Version", Info: "1.0.0"},
+		{Id: 9, ParentId: 0, Type: telemetry.CrsfString, Name: "Pilot Name", Info: "pilot"},
+		{Id: 10, ParentId: 0, Type: telemetry.CrsfCommand, Name: "Reset Settings", Step: settings.StepIdle, Timeout: 50,
+			Confirm: "Reset all settings?"},
+		{Id: 11, ParentId: 0, Type: telemetry.CrsfFloat, Name: "Stick Deadband",
+			RawValue: 25, RawMin: 0, RawMax: 200, RawDefault: 25, RawStep: 5, Precision: 1, Units: "%"},
+		{Id: 12, ParentId: 3, Type: telemetry.CrsfTextSelection, Name: "Fan Threshold", Hidden: true,
+			Options: []string{"10", "25", "50", "100", "250"},
+			Value:   2, Min: 0, Max: 4, Default: 2, Units: "mW"},
+		//-10 to 10
+		{Id: 13, ParentId: 0, Type: telemetry.CrsfInt8, Name: "Channel Trim", Value: 0, Min: 0xF6, Max: 0x0A, Default: 0, Units: "us"},
+	}
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+)
+
+type statusText struct {
+	severity uint8
+	text     string
+}
+
+// passthroughTexts are sent in turn as passthrough text packets
+var passthroughTexts = []statusText{
+	{severity: 6, text: "SIM: ArduPilot passthrough"},
+	{severity: 4, text: "PreArm: Throttle below failsafe"},
+}
+
+// passthroughState is only used by the run loop
+type passthroughState struct {
+	chunks    []uint32
+	nextText  int
+	textsSent int
+}
+
+// createPassthroughFrames builds the next passthrough frames of the simulated ArduPilot plane,
+// a multi packet frame with its state, a single packet frame with the next status text chunk,
+// and every other round of status texts, a status text frame
+func (m *Module) createPassthroughFrames() [][]byte {
+	var frames [][]byte
+
+	state := &m.passthrough
+	if len(state.chunks) == 0 {
+		text := passthroughTexts[state.nextText]
+		state.chunks = createStatusTextChunks(text.severity, text.text)
+		state.nextText = (state.nextText + 1) % len(passthroughTexts)
+
+		if state.textsSent%(2*len(passthroughTexts)) == 0 {
+			frame := crossfire.Passthrough{
+				Addr:     crossfire.HandsetEndpoint,
+				SubType:  crossfire.PassthroughStatusText,
+				Severity: 6,
+				Text:     "Frame: PLANE",
+			}
+			frames = append(frames, frame.Marshal())
+		}
+		state.textsSent++
+	}
+
+	multi := crossfire.Passthrough{
+		Addr:    crossfire.HandsetEndpoint,
+		SubType: crossfire.PassthroughMultiPacket,
+		Packets: []crossfire.PassthroughPacket{
+			//mode 10 (auto) + 1, armed, flying, throttle 45% (* 0.63), imu at 42°C
+			{AppId: crossfire.PassthroughAPStatusId, Data: 11 | 1<<7 | 1<<8 | encodePassthroughNumber(28, 2, 0)<<19 | (42-19)<<26},
+			//12 satellites, 3D fix, hdop 0.9, 152.3m msl
+			{AppId: crossfire.PassthroughGPSStatusId, Data: 12 | 3<<4 | encodePassthroughNumber(9, 2, 1)<<6 | encodePassthroughNumber(1523, 2, 2)<<22},
+			//15.8V, 12.4A, 850mAh
+			{AppId: crossfire.PassthroughBattery1Id, Data: 158 | encodePassthroughNumber(124, 2, 1)<<9 | 850<<17},
+			//430m from home at 85.0m, home is at 270°
+			{AppId: crossfire.PassthroughHomeId, Data: encodePassthroughNumber(430, 3, 2) | encodePassthroughNumber(850, 3, 2)<<12 | (270/3)<<25},
+			//climbing 1.2m/s, 18.5m/s airspeed, yaw 90°
+			{AppId: crossfire.PassthroughVelocityYawId, Data: encodePassthroughNumber(12, 2, 1) | encodePassthroughNumber(185, 2, 1)<<9 | (90*5)<<17 | 1<<28},
+			//roll 10°, pitch -5°, no rangefinder
+			{AppId: crossfire.PassthroughAttitudeRangeId, Data: (180+10)*5 | ((90-5)*5)<<11},
+			//battery 1 capacity 5000mAh
+			{AppId: crossfire.PassthroughParameterId, Data: uint32(crossfire.APParamBattery1Capacity)<<24 | 5000},
+		},
+	}
+	frames = append(frames, multi.Marshal())
+
+	single := crossfire.Passthrough{
+		Addr:    crossfire.HandsetEndpoint,
+		SubType: crossfire.PassthroughSinglePacket,
+		Packets: []crossfire.PassthroughPacket{{AppId: crossfire.PassthroughTextId, Data: state.chunks[0]}},
+	}
+	state.chunks = state.chunks[1:]
+	frames = append(frames, single.Marshal())
+
+	return frames
+}
+
+// createStatusTextChunks splits a status text into the 4 character chunks of passthrough text packets,
+// the chunk with the terminating nul carries the severity. Like ArduPilot, every chunk is sent 3 times.
+func createStatusTextChunks(severity uint8, text string) []uint32 {
+	var chunks []uint32
+	for i := 0; ; i += 4 {
+		var chunk uint32
+		for j := 0; j < 4; j++ {
+			if i+j < len(text) {
+				chunk |= uint32(text[i+j]&0x7F) << (24 - 8*j)
+			}
+		}
+
+		last := i+4 > len(text)
+		if last {
+			chunk |= uint32(severity&0x4)<<21 | uint32(severity&0x2)<<14 | uint32(severity&0x1)<<7
+		}
+		chunks = append(chunks, chunk, chunk, chunk)
+
+		if last {
+			return chunks
+		}
+	}
+}
+
+// encodePassthroughNumber packs a number the way ArduPilot does, see crossfire.passthroughNumber
+func encodePassthroughNumber(value int32, digits uint8, power uint8) uint32 {
+	mantissaBits := uint8(7)
+	if digits == 3 {
+		mantissaBits = 10
+	}
+	if power == 0 {
+		mantissaBits = 6
+	}
+
+	negative := value < 0
+	if negative {
+		value = -value
+	}
+
+	exponent := uint32(0)
+	for value >= 1<<mantissaBits && exponent < 1<<power-1 {
+		value = (value + 5) / 10
+		exponent++
+	}
+	value = min(value, 1<<mantissaBits-1)
+
+	bits := uint32(value)<<power | exponent
+	if negative {
+		bits |= 1 << (power + mantissaBits)
+	}
+	return bits
+}
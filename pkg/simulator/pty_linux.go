@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+//go:build linux
+
+package simulator
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"os"
+)
+
+// OpenPty opens a pseudo-terminal pair in raw mode. The simulator talks on the master side,
+// the returned slave name (e.g. /dev/pts/3) is what elrs-control should use as its -port.
+func OpenPty() (*os.File, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fd := int(master.Fd())
+
+	if err = unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+
+	ptyNumber, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+
+	//raw mode, no echo and no line discipline, so CRSF bytes pass through untouched
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err = unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+
+	return master, fmt.Sprintf("/dev/pts/%d", ptyNumber), nil
+}
@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+//go:build !linux
+
+package simulator
+
+import (
+	"errors"
+	"os"
+)
+
+// OpenPty is only supported on Linux
+func OpenPty() (*os.File, string, error) {
+	return nil, "", errors.New("pseudo-terminals are only supported on linux")
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: © 2023 OneEyeFPV oneeyefpv@gmail.com
+// SPDX-License-Identifier: GPL-3.0-or-later
+// SPDX-License-Identifier: FS-0.9-or-later
+
+package simulator
+
+import (
+	"github.com/kaack/elrs-joystick-control/pkg/crossfire"
+	"time"
+)
+
+// createSensorFrames builds one frame of every sensor the simulated aircraft carries,
+// a quad with four motors, a 4S battery and a GPS with a fix
+func createSensorFrames(now time.Time) [][]byte {
+	utc := now.UTC()
+
+	frames := []crossfire.Frame{
+		&crossfire.RPM{Addr: crossfire.HandsetEndpoint, SourceId: 0, Values: []int32{21000, 21150, 20900, 21050}},
+		&crossfire.Temperature{Addr: crossfire.HandsetEndpoint, SourceId: 0, Values: []int16{412, 398, 405, 420}},
+		&crossfire.Cells{Addr: crossfire.HandsetEndpoint, SourceId: 0, Voltages: []uint16{3950, 3948, 3951, 3946}},
+		&crossfire.Airspeed{Addr: crossfire.HandsetEndpoint, Speed: 425},
+		&crossfire.Heartbeat{Addr: crossfire.HandsetEndpoint, Origin: crossfire.ModuleEndpoint},
+		&crossfire.GPSTime{
+			Addr:        crossfire.HandsetEndpoint,
+			Year:        int16(utc.Year()),
+			Month:       uint8(utc.Month()),
+			Day:         uint8(utc.Day()),
+			Hour:        uint8(utc.Hour()),
+			Minute:      uint8(utc.Minute()),
+			Second:      uint8(utc.Second()),
+			Millisecond: uint16(utc.Nanosecond() / int(time.Millisecond)),
+		},
+		&crossfire.GPSExtended{
+			Addr:                    crossfire.HandsetEndpoint,
+			FixType:                 3,
+			NorthSpeed:              250,
+			EastSpeed:               -120,
+			VerticalSpeed:           15,
+			HorizontalSpeedAccuracy: 30,
+			TrackAccuracy:           25,
+			AltitudeEllipsoid:       152,
+			HorizontalAccuracy:      120,
+			VerticalAccuracy:        180,
+			HDOP:                    9,
+			VDOP:                    14,
+		},
+	}
+
+	data := make([][]byte, len(frames))
+	for i, frame := range frames {
+		data[i] = frame.Marshal()
+	}
+	return data
+}